@@ -0,0 +1,68 @@
+package xwgen
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/Eyas/xwgen/pkg/primitives"
+)
+
+// barredGrid returns the barred grid with the given rows and columns, taking the bars between
+// cells of a row from the row, and those between cells of a column from the column.
+func barredGrid(across, down []*primitives.ConcreteLine) Grid {
+	cells := make([][]rune, len(across))
+	right := make([][]bool, len(across))
+	below := make([][]bool, len(across))
+	for y, row := range across {
+		cells[y] = row.Line
+		right[y] = make([]bool, len(row.Line))
+		below[y] = make([]bool, len(row.Line))
+		for _, bar := range row.Bars {
+			right[y][bar-1] = true
+		}
+	}
+	for x, column := range down {
+		for _, bar := range column.Bars {
+			below[bar-1][x] = true
+		}
+	}
+	return NewBarredGrid(cells, right, below)
+}
+
+// lineKey returns a string that identifies a concrete line: its cells, with a '|' before every
+// cell that has a bar before it.
+func lineKey(l *primitives.ConcreteLine) string {
+	if len(l.Bars) == 0 {
+		return string(l.Line)
+	}
+	var b strings.Builder
+	for i, r := range l.Line {
+		if slices.Contains(l.Bars, i) {
+			b.WriteRune('|')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// sameLine returns true if a and b have the same cells and bars.
+func sameLine(a, b *primitives.ConcreteLine) bool {
+	return slices.Equal(a.Line, b.Line) && slices.Equal(a.Bars, b.Bars)
+}
+
+// lineBars returns, for every line, whether there is a bar after each of its cells. A line's bars
+// are known once its runs are, i.e. once it has a single possibility, and they are nil until then.
+func lineBars(lines []primitives.PossibleLines) [][]bool {
+	bars := make([][]bool, len(lines))
+	for i, line := range lines {
+		if line.MaxPossibilities() != 1 {
+			continue
+		}
+		l := line.FirstOrNull()
+		bars[i] = make([]bool, len(l.Line))
+		for _, bar := range l.Bars {
+			bars[i][bar-1] = true
+		}
+	}
+	return bars
+}
@@ -0,0 +1,61 @@
+package xwgen
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGrid_Barred(t *testing.T) {
+	grid := NewBarredGrid(
+		[][]rune{[]rune("abc"), []rune("def"), []rune("ghi")},
+		[][]bool{{false, true, false}, {false, false, false}, {true, false, false}},
+		[][]bool{{false, false, false}, {false, false, true}, {false, false, false}},
+	)
+	if !grid.Barred() {
+		t.Error("Barred() = false, want true")
+	}
+
+	wantRepr := strings.Join([]string{
+		"a b|c",
+		"",
+		"d e f",
+		"    -",
+		"g|h i",
+	}, "\n")
+	if diff := cmp.Diff(wantRepr, grid.Repr()); diff != "" {
+		t.Errorf("Repr() mismatch (-want +got):\n%s", diff)
+	}
+
+	wantWords := []string{"ab", "def", "hi", "adg", "beh", "cf"}
+	if diff := cmp.Diff(wantWords, grid.Words()); diff != "" {
+		t.Errorf("Words() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPossibleGrids_Barred(t *testing.T) {
+	gen := testGenerator(6, loadWords(t), GeneratorParams{
+		MinWordLength: 3,
+		Barred:        true,
+	})
+	// Only two cells are open, but the search places the bars as well as the letters.
+	pattern := joinRows("a.aaba", "camgal", "a.aeel", "tornos", "abscds", "played")
+	grid := func(top, middle rune) string {
+		return joinRows(
+			fmt.Sprintf("a %c a|a b a", top),
+			"",
+			"c a m|g a l",
+			"",
+			fmt.Sprintf("a %c a|e e l", middle),
+			"- - -   - -",
+			"t o r|n o s",
+			"",
+			"a b s|c d s",
+			"",
+			"p l a y e d",
+		)
+	}
+	checkGrids(t, gen, pattern, []string{grid('h', 'l'), grid('l', 'd'), grid('l', 'n'), grid('n', 'h')})
+}
@@ -0,0 +1,137 @@
+package xwgen
+
+// CheckingRules allow unchecked cells, as in British and cryptic grids: letters that belong to an
+// entry in only one direction, because their run of letters in the other direction is shorter than
+// the minimum word length.
+type CheckingRules struct {
+	// AllowUnchecked lets rows and columns hold runs of letters shorter than the minimum word length.
+	// Such runs aren't entries, and every one of their letters must belong to a crossing entry.
+	AllowUnchecked bool
+	// MinCheckedRatio is the fraction of the letters of every entry that must also belong to a
+	// crossing entry, e.g. 0.5 for the usual cryptic convention of checking at least half of each
+	// entry. Zero means any entry may be mostly unchecked. Ignored unless AllowUnchecked is set.
+	MinCheckedRatio float64
+}
+
+// runLengths returns, for every row y and column x, the length of the run of letters through the
+// cell in the given direction, or 0 if it isn't known yet, i.e. the cell or one of its neighbours
+// in the run may still be a block. It also returns the index along the line where each known run
+// starts.
+//
+// In barred grids, bars[i] holds the bars of line i in the given direction (see lineBars), and runs
+// are only known in lines whose bars are known. bars is nil in grids without bars.
+func runLengths(blocks, letters, bars [][]bool, dir Direction) (lengths, starts [][]int) {
+	height, width := len(blocks), len(blocks[0])
+	lengths = make([][]int, height)
+	starts = make([][]int, height)
+	for y := range lengths {
+		lengths[y] = make([]int, width)
+		starts[y] = make([]int, width)
+	}
+	at := func(i, j int) (x, y int) {
+		if dir == DirectionVertical {
+			return i, j
+		}
+		return j, i
+	}
+	lines, lineLength := height, width
+	if dir == DirectionVertical {
+		lines, lineLength = width, height
+	}
+
+	for i := range lines {
+		if bars != nil && bars[i] == nil {
+			continue
+		}
+		barAfter := func(j int) bool {
+			return bars != nil && bars[i][j]
+		}
+		for start := 0; start < lineLength; {
+			x, y := at(i, start)
+			if blocks[y][x] {
+				start++
+				continue
+			}
+			// A run is known once it's made of letters, and bounded by blocks or the edges of the grid.
+			end, known := start, true
+			for end < lineLength {
+				x, y := at(i, end)
+				if blocks[y][x] {
+					break
+				}
+				known = known && letters[y][x]
+				end++
+				if barAfter(end - 1) {
+					break
+				}
+			}
+			if known {
+				for j := start; j < end; j++ {
+					x, y := at(i, j)
+					lengths[y][x] = end - start
+					starts[y][x] = start
+				}
+			}
+			start = end
+		}
+	}
+	return lengths, starts
+}
+
+// breaksChecking returns true if the cells that are already definite break the rules: some letter
+// belongs to no entry at all, or some entry has too few letters checked by crossing entries. Only
+// runs whose length is known are counted.
+func breaksChecking(state *gridState, rules CheckingRules, minWordLength int) bool {
+	if !rules.AllowUnchecked || len(state.across) == 0 {
+		return false
+	}
+	g := newCellGraph(state)
+	blocks := definiteBlocks(state)
+	var acrossBars, downBars [][]bool
+	if state.config != nil && state.config.barred {
+		acrossBars, downBars = lineBars(state.across), lineBars(state.down)
+	}
+	across, acrossStarts := runLengths(blocks, g.letter, acrossBars, DirectionHorizontal)
+	down, downStarts := runLengths(blocks, g.letter, downBars, DirectionVertical)
+	unchecked := func(length int) bool {
+		return length > 0 && length < minWordLength
+	}
+
+	for y := range g.height {
+		for x := range g.width {
+			if unchecked(across[y][x]) && unchecked(down[y][x]) {
+				return true
+			}
+		}
+	}
+	if rules.MinCheckedRatio <= 0 {
+		return false
+	}
+
+	for _, dir := range []Direction{DirectionHorizontal, DirectionVertical} {
+		lengths, starts, crossing := across, acrossStarts, down
+		if dir == DirectionVertical {
+			lengths, starts, crossing = down, downStarts, across
+		}
+		// Count the letters of each known entry that are known to be unchecked, keyed by its first
+		// cell.
+		uncheckedIn := make(map[Point]int)
+		for y := range g.height {
+			for x := range g.width {
+				if lengths[y][x] < minWordLength || !unchecked(crossing[y][x]) {
+					continue
+				}
+				first := Point{X: starts[y][x], Y: y}
+				if dir == DirectionVertical {
+					first = Point{X: x, Y: starts[y][x]}
+				}
+				uncheckedIn[first]++
+				length := lengths[y][x]
+				if float64(length-uncheckedIn[first]) < rules.MinCheckedRatio*float64(length) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package xwgen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBreaksChecking(t *testing.T) {
+	cryptic := []string{"aaaaa", "a#a#a", "aaaaa", "a#a#a", "aaaaa"}
+	tests := []struct {
+		name  string
+		grid  []string
+		rules CheckingRules
+		want  bool
+	}{
+		{name: "disabled", grid: []string{"aaaaa", "a#a#a", "aaaaa", "#a#a#", "a#a#a"}, want: false},
+		{name: "fully checked", grid: []string{"aaaaa", "aaaaa", "aaaaa", "aaaaa", "aaaaa"}, rules: CheckingRules{AllowUnchecked: true, MinCheckedRatio: 1}, want: false},
+		{name: "cryptic", grid: cryptic, rules: CheckingRules{AllowUnchecked: true, MinCheckedRatio: 0.5}, want: false},
+		{name: "too few checked", grid: cryptic, rules: CheckingRules{AllowUnchecked: true, MinCheckedRatio: 0.7}, want: true},
+		{name: "any ratio", grid: cryptic, rules: CheckingRules{AllowUnchecked: true}, want: false},
+		{name: "in no entry", grid: []string{"aaaaa", "a#a#a", "aaaaa", "#a#a#", "a#a#a"}, rules: CheckingRules{AllowUnchecked: true}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grid := mustParseGrid(t, strings.Join(tt.grid, "\n"))
+			if got := breaksChecking(stateOf(t, grid), tt.rules, 3); got != tt.want {
+				t.Errorf("breaksChecking(%+v) = %v, want %v", tt.rules, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrid_Entries_Cryptic(t *testing.T) {
+	grid := mustParseGrid(t, strings.Join([]string{"abcde", "f#g#h", "ij#kl", "m#n#o", "pqrst"}, "\n"))
+
+	// "ij", "kl", "cg" and "nr" are too short to be entries: their letters are only checked by the
+	// columns and rows they cross.
+	wantWords := []string{"abcde", "ij", "kl", "pqrst", "afimp", "cg", "nr", "ehlot"}
+	if diff := cmp.Diff(wantWords, grid.Words()); diff != "" {
+		t.Errorf("Words() mismatch (-want +got):\n%s", diff)
+	}
+	wantEntries := []string{"abcde", "pqrst", "afimp", "ehlot"}
+	if diff := cmp.Diff(wantEntries, grid.Entries(3)); diff != "" {
+		t.Errorf("Entries(3) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPossibleGrids_Unchecked(t *testing.T) {
+	// Only the rows are entries: the letters between the blocks are checked by no other word.
+	words := []string{"abate", "about", "agave", "along", "eager", "enter"}
+	pattern := joinRows(".....", ".`.`.", ".....", ".`.`.", ".....")
+	tests := []struct {
+		name  string
+		ratio float64
+		want  []string
+	}{
+		{name: "half checked", ratio: 0.5, want: []string{
+			joinRows("abate", "g`b`a", "along", "v`u`e", "enter"),
+			joinRows("abate", "g`l`n", "about", "v`n`e", "eager"),
+			joinRows("agave", "b`b`a", "along", "t`u`e", "enter"),
+			joinRows("agave", "b`l`n", "about", "t`n`e", "eager"),
+		}},
+		{name: "too few checked", ratio: 0.7, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := testGenerator(5, words, GeneratorParams{
+				MinWordLength: 3,
+				Checking:      CheckingRules{AllowUnchecked: true, MinCheckedRatio: tt.ratio},
+			})
+			checkGrids(t, gen, pattern, tt.want)
+		})
+	}
+}
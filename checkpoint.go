@@ -0,0 +1,272 @@
+package xwgen
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"time"
+)
+
+// Checkpoint is the state of a search started by PossibleGrids or PossibleGridsFrom, from which
+// GeneratorParams.Resume continues it, with the same words, options and pattern. Searches with
+// RestartBase or Diversity can't be checkpointed.
+type Checkpoint struct {
+	data checkpointData
+}
+
+// checkpointData holds the fields of a Checkpoint, exported for encoding/gob.
+type checkpointData struct {
+	LineLength int
+	// Fingerprint is a hash of the words, options and pattern of the search (see
+	// Generator.fingerprint). The branches in Path only lead to the same nodes in a search with the
+	// same fingerprint.
+	Fingerprint uint64
+	// LinesSeed seeds the order of the possible lines, which must be the same for the choices in
+	// Path to lead to the same nodes.
+	LinesSeed [2]uint64
+	// Rand is the state of the search's random source.
+	Rand []byte
+	// Path lists the choices that led to the node the search was at.
+	Path []CheckpointStep
+	// Done is true if the search had explored everything.
+	Done bool
+	Seen seenSnapshot
+}
+
+// CheckpointStep is a single choice on the path to a node of a search: the line that was decided,
+// and which of the branches of that line was taken, counting from zero.
+type CheckpointStep struct {
+	Dir    Direction
+	Index  int
+	Branch int
+}
+
+// Done returns true if the search had explored everything, so resuming it yields no grids.
+func (c *Checkpoint) Done() bool {
+	return c.data.Done
+}
+
+// Remove removes the files the checkpoint keeps on disk, once it won't be resumed again. Only
+// checkpoints of searches that spilled grids to Dedup.SpillDir have any.
+func (c *Checkpoint) Remove() error {
+	if c.data.Seen.SpillFile == "" {
+		return nil
+	}
+	return os.Remove(c.data.Seen.SpillFile)
+}
+
+// Write writes the checkpoint to w.
+func (c *Checkpoint) Write(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(c.data)
+}
+
+// ReadCheckpoint reads a checkpoint written by Checkpoint.Write.
+func ReadCheckpoint(r io.Reader) (*Checkpoint, error) {
+	var c Checkpoint
+	if err := gob.NewDecoder(r).Decode(&c.data); err != nil {
+		return nil, fmt.Errorf("reading checkpoint: %w", err)
+	}
+	return &c, nil
+}
+
+// searchTrail follows a running search to take checkpoints of it, and steers a resumed search
+// along the path of its checkpoint. A nil trail does nothing.
+type searchTrail struct {
+	// fingerprint is the fingerprint of the search.
+	fingerprint uint64
+	// steps[l] is the branch taken at depth l on the path to the node the search is at, or last
+	// stepped into.
+	steps []CheckpointStep
+	done  bool
+	// resume lists the steps of the checkpoint's path that are still to be followed. Once the
+	// search moves past the branch resume[l], it is truncated to l.
+	resume []CheckpointStep
+
+	// save, if set, is called about every interval with a checkpoint of the search.
+	save         func()
+	interval     time.Duration
+	lastSaved    time.Time
+	nodesVisited int64
+}
+
+// visit records that the search is at a node of the given depth, and saves a checkpoint if one is
+// due.
+func (t *searchTrail) visit(depth int) {
+	if t == nil {
+		return
+	}
+	t.steps = t.steps[:depth]
+	t.nodesVisited++
+	if t.save != nil && t.nodesVisited%observeCheckInterval == 0 && time.Since(t.lastSaved) >= t.interval {
+		t.lastSaved = time.Now()
+		t.save()
+	}
+}
+
+// resumeStep returns the line to decide at a node of the given depth, if it is on the path being
+// resumed.
+func (t *searchTrail) resumeStep(depth int) (CheckpointStep, bool) {
+	if t == nil || depth >= len(t.resume) {
+		return CheckpointStep{}, false
+	}
+	return t.resume[depth], true
+}
+
+// skip returns true if the given branch of a node at the given depth was already explored before
+// the checkpoint being resumed.
+func (t *searchTrail) skip(depth, branch int) bool {
+	if t == nil || depth >= len(t.resume) {
+		return false
+	}
+	if branch < t.resume[depth].Branch {
+		return true
+	}
+	if branch > t.resume[depth].Branch {
+		t.resume = t.resume[:depth]
+	}
+	return false
+}
+
+// enter records that the search takes the given branch of a line at the given depth.
+func (t *searchTrail) enter(depth int, dir Direction, index, branch int) {
+	if t == nil {
+		return
+	}
+	t.steps = append(t.steps[:depth], CheckpointStep{Dir: dir, Index: index, Branch: branch})
+}
+
+// Checkpoint returns the state of the most recent search, to resume it later with
+// GeneratorParams.Resume. It can be called while the search is paused between two grids, or once
+// it has ended, e.g. because its context was done. It returns an error if there is no search to
+// checkpoint.
+//
+// With Dedup.SpillDir, the checkpoint keeps a copy of the grid hashes spilled to disk in SpillDir,
+// until Remove is called.
+func (g *Generator) Checkpoint() (*Checkpoint, error) {
+	if g.trail == nil || g.seen == nil {
+		return nil, errors.New("no search to checkpoint")
+	}
+	if g.restarts() {
+		return nil, errors.New("searches with restarts can't be checkpointed")
+	}
+	state, err := g.searchSource.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	seen, err := g.seen.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &Checkpoint{data: checkpointData{
+		LineLength:  g.LineLength,
+		Fingerprint: g.trail.fingerprint,
+		LinesSeed:   g.linesSeed,
+		Rand:        state,
+		Path:        slices.Clone(g.trail.steps),
+		Done:        g.trail.done,
+		Seen:        seen,
+	}}, nil
+}
+
+// Close releases what the most recent search keeps to be checkpointed, i.e. the grid hashes
+// spilled to Dedup.SpillDir. Starting another search does the same.
+func (g *Generator) Close() error {
+	if g.seen == nil {
+		return nil
+	}
+	err := g.seen.close()
+	g.seen = nil
+	return err
+}
+
+// fingerprint returns a hash of everything that decides the shape of a search: the words, the
+// options that narrow them down or prune branches, and pattern, which identifies what the search
+// fills.
+func (g *Generator) fingerprint(pattern string) uint64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d %q\n", g.LineLength, pattern)
+	for _, tier := range g.Tiers {
+		fmt.Fprintf(h, "tier %v %d %q\n", tier.Weight, tier.MaxEntries, tier.Words)
+	}
+	fmt.Fprintf(h, "excluded %q %q\n", g.ExcludedWords, g.ExcludedSubstrings)
+	for _, p := range g.ExcludedPatterns {
+		fmt.Fprintf(h, "pattern %q\n", p.String())
+	}
+	minWordLength, maxWordLength := 0, 0
+	if g.MinWordLength != nil {
+		minWordLength = *g.MinWordLength
+	}
+	if g.MaxWordLength != nil {
+		maxWordLength = *g.MaxWordLength
+	}
+	fmt.Fprintf(h, "lengths %d %d\n", minWordLength, maxWordLength)
+	fmt.Fprintf(h, "related %T %v\n", g.Related, g.Related)
+	fmt.Fprintf(h, "low score %d %v\n", g.MaxLowScoreEntries, g.LowScoreThreshold)
+	fmt.Fprintf(h, "rules %v %+v %+v %v %v\n", g.NoNestedEntries, g.Structure, g.Checking, g.Barred, g.DedupSymmetries)
+	fmt.Fprintf(h, "search %v %v\n", g.FixedPassPropagation, g.DisableConflictLearning)
+	return h.Sum64()
+}
+
+// startTrail sets up the random source, the set of grids already seen and the trail of a new
+// search, or of the one in g.Resume, which is cleared once it is resumed. pattern identifies what
+// the search fills, as for fingerprint.
+func (g *Generator) startTrail(pattern string) error {
+	if err := g.Close(); err != nil {
+		return fmt.Errorf("dedup: %w", err)
+	}
+	g.trail = &searchTrail{fingerprint: g.fingerprint(pattern), interval: g.CheckpointInterval, lastSaved: time.Now()}
+	if g.OnCheckpoint != nil {
+		g.trail.save = func() {
+			if c, err := g.Checkpoint(); err == nil {
+				g.OnCheckpoint(c)
+			}
+		}
+	}
+
+	if g.Resume == nil {
+		g.searchSource = rand.NewPCG(g.randUint64(), g.randUint64())
+		var err error
+		if g.seen, err = newSeenSet(g.Dedup); err != nil {
+			return fmt.Errorf("dedup: %w", err)
+		}
+		return nil
+	}
+
+	c := g.Resume.data
+	switch {
+	case g.restarts():
+		return errors.New("searches with restarts can't be resumed")
+	case c.LineLength != g.LineLength:
+		return fmt.Errorf("the checkpoint is for grids of size %d, not %d", c.LineLength, g.LineLength)
+	case c.Fingerprint != g.trail.fingerprint:
+		return errors.New("the checkpoint is for a search with different words, options or pattern")
+	case g.lazyAllPossibleLines != nil && g.linesSeed != c.LinesSeed:
+		return errors.New("the generator has already searched with a different order of lines")
+	}
+	g.linesSeed, g.hasLinesSeed = c.LinesSeed, true
+	g.searchSource = &rand.PCG{}
+	if err := g.searchSource.UnmarshalBinary(c.Rand); err != nil {
+		return fmt.Errorf("restoring the random source: %w", err)
+	}
+	var err error
+	if g.seen, err = restoreSeenSet(g.Dedup, c.Seen); err != nil {
+		return fmt.Errorf("dedup: %w", err)
+	}
+	g.trail.resume = slices.Clone(c.Path)
+	g.trail.done = c.Done
+	g.Resume = nil
+	return nil
+}
+
+// randUint64 returns a random number from the generator's source, or the global one if it has none.
+func (g *Generator) randUint64() uint64 {
+	if g.rand == nil {
+		return rand.Uint64()
+	}
+	return g.rand.Uint64()
+}
@@ -0,0 +1,278 @@
+package xwgen
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// checkpointTest builds generators that resume from a checkpoint, and collects the grids they
+// yield.
+type checkpointTest struct {
+	t       *testing.T
+	words   []string
+	pattern Grid
+	// dedup and dedupSymmetries set how the generators remember the grids they yielded.
+	dedup           DedupOptions
+	dedupSymmetries bool
+}
+
+func newCheckpointTest(t *testing.T) checkpointTest {
+	return checkpointTest{
+		t:       t,
+		words:   loadWords(t),
+		pattern: mustParseGrid(t, strings.Join([]string{"...`", "....", "....", "`..."}, "\n")),
+	}
+}
+
+func (c checkpointTest) generator(resume *Checkpoint, onCheckpoint func(*Checkpoint)) *Generator {
+	return CreateGenerator(c.pattern.Width(), PreferredAndObscureTiers(c.words, nil), nil, rand.New(rand.NewPCG(1, 2)), GeneratorParams{
+		MinWordLength:   3,
+		Dedup:           c.dedup,
+		DedupSymmetries: c.dedupSymmetries,
+		Resume:          resume,
+		OnCheckpoint:    onCheckpoint,
+	})
+}
+
+// collect returns the reprs of the grids gen yields, stopping after limit grids if it is set.
+func (c checkpointTest) collect(ctx context.Context, gen *Generator, limit int) []string {
+	t := c.t
+	t.Helper()
+	grids, err := gen.PossibleGridsFrom(ctx, c.pattern)
+	if err != nil {
+		t.Fatalf("PossibleGridsFrom() = %v", err)
+	}
+	var reprs []string
+	for grid := range grids {
+		reprs = append(reprs, grid.Repr())
+		if len(reprs) == limit {
+			break
+		}
+	}
+	if err := gen.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	return reprs
+}
+
+// resume writes and reads back checkpoint, resumes the search from it, and checks that the grids
+// yielded before and after the checkpoint are exactly all of them. It returns the resumed generator.
+func (c checkpointTest) resume(checkpoint *Checkpoint, before, all []string) *Generator {
+	t := c.t
+	t.Helper()
+	var buf bytes.Buffer
+	if err := checkpoint.Write(&buf); err != nil {
+		t.Fatalf("Write() = %v", err)
+	}
+	checkpoint, err := ReadCheckpoint(&buf)
+	if err != nil {
+		t.Fatalf("ReadCheckpoint() = %v", err)
+	}
+
+	resumed := c.generator(checkpoint, nil)
+	rest := c.collect(context.Background(), resumed, 0)
+	seen := make(map[string]bool)
+	for _, repr := range append(slices.Clone(before), rest...) {
+		if seen[repr] {
+			t.Errorf("grid yielded twice:\n%s", repr)
+		}
+		seen[repr] = true
+	}
+	for _, repr := range all {
+		if !seen[repr] {
+			t.Errorf("grid missing after resuming:\n%s", repr)
+		}
+	}
+	return resumed
+}
+
+func TestCheckpoint_Resume(t *testing.T) {
+	c := newCheckpointTest(t)
+	full := c.generator(nil, nil)
+	all := c.collect(context.Background(), full, 0)
+	if len(all) < 10 {
+		t.Fatalf("got %d grids, want enough to checkpoint halfway", len(all))
+	}
+
+	gen := c.generator(nil, nil)
+	first := c.collect(context.Background(), gen, len(all)/2)
+	checkpoint, err := gen.Checkpoint()
+	if err != nil {
+		t.Fatalf("Checkpoint() = %v", err)
+	}
+	resumed := c.resume(checkpoint, first, all)
+
+	// The resumed search skips the branches explored before the checkpoint.
+	if resumed.SearchStats().Nodes >= full.SearchStats().Nodes {
+		t.Errorf("resumed search expanded %d nodes, want fewer than the %d of the whole search", resumed.SearchStats().Nodes, full.SearchStats().Nodes)
+	}
+
+	done, err := resumed.Checkpoint()
+	if err != nil {
+		t.Fatalf("Checkpoint() = %v", err)
+	}
+	if !done.Done() {
+		t.Error("Done() = false after exploring everything, want true")
+	}
+	if again := c.collect(context.Background(), c.generator(done, nil), 0); len(again) != 0 {
+		t.Errorf("resuming a finished search yielded %d grids, want none", len(again))
+	}
+}
+
+func TestCheckpoint_SpilledAfterStop(t *testing.T) {
+	// With every word over "ab", the reversal of a word is a word too, so every grid has rotations and
+	// reflections that only the remembered hashes keep from being yielded again after resuming.
+	c := newCheckpointTest(t)
+	c.words = nil
+	for i := range 16 {
+		c.words = append(c.words, strings.Map(func(r rune) rune { return 'a' + r - '0' }, fmt.Sprintf("%04b", i)))
+	}
+	c.pattern = mustParseGrid(t, strings.Join([]string{"....", "....", "....", "...."}, "\n"))
+	dir := t.TempDir()
+	c.dedup, c.dedupSymmetries = DedupOptions{MaxEntries: 3, SpillDir: dir}, true
+	all := c.collect(context.Background(), c.generator(nil, nil), 0)
+
+	// The spilled hashes outlive the search, and the checkpoint refers to a copy of them rather than
+	// listing them.
+	gen := c.generator(nil, nil)
+	first := c.collect(context.Background(), gen, 20)
+	checkpoint, err := gen.Checkpoint()
+	if err != nil {
+		t.Fatalf("Checkpoint() = %v", err)
+	}
+	if n := len(checkpoint.data.Seen.Hashes); n > 3 {
+		t.Errorf("checkpoint lists %d hashes, want at most MaxEntries", n)
+	}
+	rest := c.collect(context.Background(), c.generator(checkpoint, nil), 0)
+	seen := make(map[string]bool)
+	for _, repr := range append(first, rest...) {
+		canonical := mustParseGrid(t, repr).Canonical().Repr()
+		if seen[canonical] {
+			t.Errorf("grid yielded twice, up to symmetry:\n%s", repr)
+		}
+		seen[canonical] = true
+	}
+	if len(seen) != len(all) {
+		t.Errorf("got %d grids before and after the checkpoint, want %d", len(seen), len(all))
+	}
+
+	if err := checkpoint.Remove(); err != nil {
+		t.Errorf("Remove() = %v", err)
+	}
+	if _, err := os.Stat(checkpoint.data.Seen.SpillFile); !os.IsNotExist(err) {
+		t.Errorf("the checkpoint's spill file is still there after Remove(): %v", err)
+	}
+
+	// A copy that can't be saved is reported rather than leaving the hashes out.
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gen.Checkpoint(); err == nil {
+		t.Error("Checkpoint() without a SpillDir to copy to = nil error, want an error")
+	}
+}
+
+func TestCheckpoint_Canceled(t *testing.T) {
+	c := newCheckpointTest(t)
+	all := c.collect(context.Background(), c.generator(nil, nil), 0)
+
+	// Cancel the search between two nodes, possibly after it has stepped into branches it hasn't
+	// expanded yet, as a timeout would.
+	for _, saves := range []int{1, 3, 10} {
+		ctx, cancel := context.WithCancel(context.Background())
+		n := 0
+		gen := c.generator(nil, func(*Checkpoint) {
+			if n++; n == saves {
+				cancel()
+			}
+		})
+		before := c.collect(ctx, gen, 0)
+		cancel()
+		checkpoint, err := gen.Checkpoint()
+		if err != nil {
+			t.Fatalf("Checkpoint() = %v", err)
+		}
+		if checkpoint.Done() {
+			t.Fatalf("Done() = true after %d saves, want a search still in progress", saves)
+		}
+		c.resume(checkpoint, before, all)
+	}
+}
+
+func TestCheckpoint_Restarts(t *testing.T) {
+	gen := CreateGenerator(4, PreferredAndObscureTiers(loadWords(t), nil), nil, rand.New(rand.NewPCG(1, 2)), GeneratorParams{
+		RestartBase: 10,
+	})
+	for range gen.PossibleGrids(context.Background()) {
+		break
+	}
+	if _, err := gen.Checkpoint(); err == nil {
+		t.Error("Checkpoint() with restarts = nil error, want an error")
+	}
+}
+
+func TestCheckpoint_ResumeMismatch(t *testing.T) {
+	c := newCheckpointTest(t)
+	gen := c.generator(nil, nil)
+	c.collect(context.Background(), gen, 5)
+	checkpoint, err := gen.Checkpoint()
+	if err != nil {
+		t.Fatalf("Checkpoint() = %v", err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		change func(*checkpointTest)
+	}{
+		{name: "words", change: func(c *checkpointTest) { c.words = c.words[1:] }},
+		{name: "pattern", change: func(c *checkpointTest) {
+			c.pattern = mustParseGrid(t, strings.Join([]string{"`...", "....", "....", "...`"}, "\n"))
+		}},
+		{name: "options", change: func(c *checkpointTest) { c.dedupSymmetries = true }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			changed := c
+			tc.change(&changed)
+			gen := changed.generator(checkpoint, nil)
+			grids, err := gen.PossibleGridsFrom(context.Background(), changed.pattern)
+			if err != nil {
+				t.Fatalf("PossibleGridsFrom() = %v", err)
+			}
+			for range grids {
+				t.Fatal("resuming with a different search yielded a grid")
+			}
+			if gen.Err() == nil {
+				t.Error("Err() = nil after resuming with a different search, want an error")
+			}
+		})
+	}
+}
+
+func TestCheckpoint_ResumeOnce(t *testing.T) {
+	c := newCheckpointTest(t)
+	all := c.collect(context.Background(), c.generator(nil, nil), 0)
+	gen := c.generator(nil, nil)
+	c.collect(context.Background(), gen, len(all)/2)
+	checkpoint, err := gen.Checkpoint()
+	if err != nil {
+		t.Fatalf("Checkpoint() = %v", err)
+	}
+
+	// Only the first search of the generator resumes the checkpoint; the next one starts over.
+	resumed := c.generator(checkpoint, nil)
+	if rest := c.collect(context.Background(), resumed, 0); len(rest) >= len(all) {
+		t.Errorf("resumed search yielded %d grids, want fewer than %d", len(rest), len(all))
+	}
+	if resumed.Resume != nil {
+		t.Error("Resume is still set after resuming")
+	}
+	if again := c.collect(context.Background(), resumed, 0); len(again) != len(all) {
+		t.Errorf("second search yielded %d grids, want all %d", len(again), len(all))
+	}
+}
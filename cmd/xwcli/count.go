@@ -0,0 +1,77 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/Eyas/xwgen"
+)
+
+// runCount implements "xwcli count [flags]", which counts the grids the generator can fill, in total
+// and by block pattern.
+func runCount(args []string) {
+	fs := flag.NewFlagSet("count", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: xwcli count [flags]")
+		fmt.Fprintln(fs.Output(), "Counts every grid the generator can fill, without printing them. Rotations and reflections of a grid count separately.")
+		fs.PrintDefaults()
+	}
+	generatorFlags := addGeneratorFlags(fs)
+	byPattern := fs.Bool("by_pattern", false, "Also print the number of grids of each block pattern, most common first")
+	timeout := fs.Duration("timeout", 10*time.Minute, "The timeout for the count")
+	fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	tiers, excludedWords, err := generatorFlags.loadWords(ctx, os.Stderr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error", err)
+		os.Exit(1)
+	}
+	params, err := generatorFlags.params()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error", err)
+		os.Exit(1)
+	}
+	pattern, hasPattern, err := generatorFlags.pattern()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error", err)
+		os.Exit(1)
+	}
+
+	randSource := rand.NewPCG(uint64(time.Now().UnixNano()), uint64(time.Now().Nanosecond()))
+	gen := xwgen.CreateGenerator(*generatorFlags.sideLength, tiers, excludedWords, rand.New(randSource), params)
+
+	var count xwgen.GridCount
+	if hasPattern {
+		count, err = gen.CountFrom(ctx, pattern)
+	} else {
+		count, err = gen.Count(ctx)
+	}
+
+	if *byPattern {
+		patterns := slices.SortedFunc(maps.Keys(count.ByPattern), func(a, b string) int {
+			return cmp.Or(cmp.Compare(count.ByPattern[b], count.ByPattern[a]), cmp.Compare(a, b))
+		})
+		for _, pattern := range patterns {
+			fmt.Println("--------------------------------")
+			fmt.Println(pattern)
+			fmt.Println("Grids:", count.ByPattern[pattern])
+		}
+		fmt.Println("--------------------------------")
+		fmt.Println("Patterns:", len(count.ByPattern))
+	}
+	fmt.Println("Grids:", count.Total)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err, "(the count is incomplete)")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Eyas/xwgen"
+)
+
+// tierSpec is a single word-list tier given on the command line, as
+// "name=path[,weight=W][,cap=N]".
+type tierSpec struct {
+	name       string
+	path       string
+	weight     float64
+	maxEntries int
+}
+
+// tierFlags collects repeated -list flags, in order.
+type tierFlags []tierSpec
+
+func (f *tierFlags) String() string {
+	specs := make([]string, len(*f))
+	for i, s := range *f {
+		specs[i] = fmt.Sprintf("%s=%s,weight=%g,cap=%d", s.name, s.path, s.weight, s.maxEntries)
+	}
+	return strings.Join(specs, " ")
+}
+
+func (f *tierFlags) Set(value string) error {
+	name, rest, ok := strings.Cut(value, "=")
+	if !ok || name == "" || rest == "" {
+		return fmt.Errorf("expected name=path[,weight=W][,cap=N], got %q", value)
+	}
+
+	parts := strings.Split(rest, ",")
+	spec := tierSpec{name: name, path: parts[0], weight: 1}
+	for _, opt := range parts[1:] {
+		key, val, ok := strings.Cut(opt, "=")
+		if !ok {
+			return fmt.Errorf("expected key=value in %q", opt)
+		}
+		var err error
+		switch key {
+		case "weight":
+			spec.weight, err = strconv.ParseFloat(val, 64)
+		case "cap":
+			spec.maxEntries, err = strconv.Atoi(val)
+		default:
+			err = fmt.Errorf("unknown option %q", key)
+		}
+		if err != nil {
+			return fmt.Errorf("tier %s: %w", name, err)
+		}
+	}
+
+	*f = append(*f, spec)
+	return nil
+}
+
+// stringsFlag collects a repeated string flag, in order.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, " ")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// wordFlags are the flags shared by every command that loads words: the word lists, and the rules
+// that exclude words or relate them to each other.
+type wordFlags struct {
+	file         *string
+	obscureFile  *string
+	excludedFile *string
+	lists        tierFlags
+
+	excludedPatterns   stringsFlag
+	excludedSubstrings stringsFlag
+	stem               *bool
+	relatedFile        *string
+}
+
+// addWordFlags defines the word flags on fs.
+func addWordFlags(fs *flag.FlagSet) *wordFlags {
+	f := &wordFlags{}
+	f.file = fs.String("file", "", "The file to load words from")
+	f.obscureFile = fs.String("obscure", "", "The file to load obscure words from")
+	f.excludedFile = fs.String("excluded", "", "The file to load excluded words from")
+	fs.Var(&f.lists, "list", "A word list tier, as name=path[,weight=W][,cap=N]. Repeatable; earlier tiers are tried first. The weight is the score of the tier's words, and doesn't change the search order. Added after -file and -obscure.")
+	fs.Var(&f.excludedPatterns, "exclude_pattern", "Exclude words matching a glob (e.g. '*ing'), or a regular expression if prefixed with 're:'. Repeatable")
+	fs.Var(&f.excludedSubstrings, "exclude_substring", "Exclude words containing a substring. Repeatable")
+	f.stem = fs.Bool("stem", false, "Treat inflections of the same English word (e.g. see and sees) as duplicates")
+	f.relatedFile = fs.String("related", "", "A file of related words, one class per line, to treat as duplicates")
+	return f
+}
+
+// load loads the word-list tiers and the excluded words with lengths between minLength and
+// maxLength (0 for no bound), reporting progress to out.
+func (f *wordFlags) load(ctx context.Context, out io.Writer, minLength, maxLength int) ([]xwgen.WordTier, []string, error) {
+	tiers, err := loadTiers(ctx, out, *f.file, *f.obscureFile, f.lists, minLength, maxLength)
+	if err != nil {
+		return nil, nil, err
+	}
+	var excludedWords []string
+	if *f.excludedFile != "" {
+		fmt.Fprintln(out, "Loading excluded words from file...")
+		if excludedWords, err = loadFromFile(ctx, *f.excludedFile, minLength, maxLength); err != nil {
+			return nil, nil, fmt.Errorf("loading excluded words from file: %w", err)
+		}
+	}
+	return tiers, excludedWords, nil
+}
+
+// params returns the generator parameters set by the word flags.
+func (f *wordFlags) params() (xwgen.GeneratorParams, error) {
+	var patterns []*regexp.Regexp
+	for _, p := range f.excludedPatterns {
+		var re *regexp.Regexp
+		var err error
+		if expr, ok := strings.CutPrefix(p, "re:"); ok {
+			re, err = regexp.Compile(expr)
+		} else {
+			re, err = xwgen.GlobPattern(p)
+		}
+		if err != nil {
+			return xwgen.GeneratorParams{}, fmt.Errorf("parsing excluded pattern %q: %w", p, err)
+		}
+		patterns = append(patterns, re)
+	}
+
+	var relations []xwgen.WordRelation
+	if *f.relatedFile != "" {
+		file, err := os.Open(*f.relatedFile)
+		if err != nil {
+			return xwgen.GeneratorParams{}, fmt.Errorf("opening related words file: %w", err)
+		}
+		classes, err := xwgen.LoadEquivalenceClasses(file)
+		file.Close()
+		if err != nil {
+			return xwgen.GeneratorParams{}, fmt.Errorf("loading related words file: %w", err)
+		}
+		relations = append(relations, classes)
+	}
+	if *f.stem {
+		relations = append(relations, xwgen.EnglishStemmer{})
+	}
+	var related xwgen.WordRelation
+	if len(relations) > 0 {
+		related = xwgen.ChainRelations(relations...)
+	}
+
+	return xwgen.GeneratorParams{
+		ExcludedPatterns:   patterns,
+		ExcludedSubstrings: f.excludedSubstrings,
+		Related:            related,
+	}, nil
+}
+
+// generatorFlags are the flags shared by the commands that fill grids: the word flags, the rules
+// the grids follow and the pattern they fill.
+type generatorFlags struct {
+	words         *wordFlags
+	sideLength    *int
+	minWordLength *int
+
+	maxLowScore       *int
+	lowScoreThreshold *float64
+	noBlockClumps     *bool
+	noCheaterSquares  *bool
+	maxEdgeBlockRun   *int
+	minConnectivity   *int
+	minRegionSize     *int
+	unchecked         *bool
+	minCheckedRatio   *float64
+	barred            *bool
+	noNestedEntries   *bool
+
+	patternFile *string
+	progress    *time.Duration
+}
+
+// addGeneratorFlags defines the shared flags on fs.
+func addGeneratorFlags(fs *flag.FlagSet) *generatorFlags {
+	f := &generatorFlags{words: addWordFlags(fs)}
+	f.sideLength = fs.Int("width", 4, "The width of the grid")
+	f.minWordLength = fs.Int("min_length", 3, "The minimum word length")
+
+	f.maxLowScore = fs.Int("max_low_score", 0, "The maximum number of low-score entries per grid (0 for no limit)")
+	f.lowScoreThreshold = fs.Float64("low_score_threshold", 0, "Entries from tiers with a weight below this are low-score. If 0, entries from any tier but the first are low-score")
+	f.noBlockClumps = fs.Bool("no_block_clumps", false, "Reject grids with a 2x2 square of blocks")
+	f.noCheaterSquares = fs.Bool("no_cheater_squares", false, "Reject grids with cheater squares, i.e. blocks that don't change the number of entries")
+	f.maxEdgeBlockRun = fs.Int("max_edge_block_run", 0, "The maximum number of consecutive blocks along an edge of the grid (0 for no limit)")
+	f.minConnectivity = fs.Int("min_connectivity", 0, "Reject grids where a region is joined to the rest through fewer than this many cells (0 for no requirement)")
+	f.minRegionSize = fs.Int("min_region_size", 0, "The number of letters a region needs for -min_connectivity to apply (0 means -min_length)")
+	f.unchecked = fs.Bool("unchecked", false, "Allow unchecked cells, i.e. letters in only one entry, as in cryptic grids")
+	f.minCheckedRatio = fs.Float64("min_checked_ratio", 0.5, "With -unchecked, the fraction of each entry's letters that must be checked")
+	f.barred = fs.Bool("barred", false, "Separate entries with bars between cells instead of blocks")
+	f.noNestedEntries = fs.Bool("no_nested_entries", false, "Reject grids where one entry contains another")
+
+	f.patternFile = fs.String("pattern", "", "A file with a pattern to fill: one row per line, with letters, blocks ('#' or '`'), '.' for any letter and '?' for a letter or block")
+	f.progress = fs.Duration("progress", 0, "Print a summary of the search progress to stderr at this interval, e.g. 5s (0 means never)")
+	return f
+}
+
+// loadWords loads the word-list tiers and the excluded words, reporting progress to out.
+func (f *generatorFlags) loadWords(ctx context.Context, out io.Writer) ([]xwgen.WordTier, []string, error) {
+	return f.words.load(ctx, out, *f.minWordLength, *f.sideLength)
+}
+
+// params returns the generator parameters set by the flags. Those that are specific to a command
+// are left for it to set.
+func (f *generatorFlags) params() (xwgen.GeneratorParams, error) {
+	params, err := f.words.params()
+	if err != nil {
+		return xwgen.GeneratorParams{}, err
+	}
+
+	var observer func(xwgen.SearchStats)
+	if *f.progress > 0 {
+		observer = func(stats xwgen.SearchStats) {
+			fmt.Fprintln(os.Stderr, "Progress:", stats)
+		}
+	}
+
+	params.MinWordLength = 3
+	params.MaxWordLength = *f.sideLength
+	params.MaxLowScoreEntries = *f.maxLowScore
+	params.LowScoreThreshold = *f.lowScoreThreshold
+	params.NoNestedEntries = *f.noNestedEntries
+	params.Structure = xwgen.StructureRules{
+		NoBlockClumps:    *f.noBlockClumps,
+		NoCheaterSquares: *f.noCheaterSquares,
+		MaxEdgeBlockRun:  *f.maxEdgeBlockRun,
+		MinConnectivity:  *f.minConnectivity,
+		MinRegionSize:    *f.minRegionSize,
+	}
+	params.Checking = xwgen.CheckingRules{
+		AllowUnchecked:  *f.unchecked,
+		MinCheckedRatio: *f.minCheckedRatio,
+	}
+	params.Barred = *f.barred
+	params.Observer = observer
+	params.ObserveInterval = *f.progress
+	return params, nil
+}
+
+// pattern returns the pattern of the -pattern flag, and false if it isn't set.
+func (f *generatorFlags) pattern() (xwgen.Grid, bool, error) {
+	if *f.patternFile == "" {
+		return xwgen.Grid{}, false, nil
+	}
+	data, err := os.ReadFile(*f.patternFile)
+	if err != nil {
+		return xwgen.Grid{}, false, fmt.Errorf("reading pattern: %w", err)
+	}
+	pattern, err := xwgen.ParseGrid(string(data))
+	if err != nil {
+		return xwgen.Grid{}, false, fmt.Errorf("parsing pattern: %w", err)
+	}
+	return pattern, true, nil
+}
@@ -1,28 +1,54 @@
 package main
 
 import (
-	"bufio"
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"math/rand/v2"
 	"os"
+	"path/filepath"
 	"runtime/pprof"
-	"strings"
 	"time"
 
 	"github.com/Eyas/xwgen"
+	"github.com/Eyas/xwgen/pkg/wordlist"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "query" {
+		runQuery(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "square" {
+		runSquare(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "count" {
+		runCount(os.Args[2:])
+		return
+	}
 
 	firstOnly := flag.Bool("first", false, "Only generate the first grid")
 	doAll := flag.Bool("all", false, "Generate all grids")
-	sideLength := flag.Int("width", 4, "The width of the grid")
-	minWordLength := flag.Int("min_length", 3, "The minimum word length")
-	file := flag.String("file", "", "The file to load words from")
-	obscureFile := flag.String("obscure", "", "The file to load obscure words from")
-	excludedFile := flag.String("excluded", "", "The file to load excluded words from")
+	generatorFlags := addGeneratorFlags(flag.CommandLine)
+	dedupSymmetries := flag.Bool("dedup_symmetries", false, "Only generate one grid out of those that are rotations or reflections of one another")
+	dedupMaxEntries := flag.Int("dedup_max_entries", 0, "The number of yielded grids remembered in memory to skip duplicates (0 for no limit)")
+	dedupSpillDir := flag.String("dedup_spill_dir", "", "With -dedup_max_entries, a directory to write the remembered grids that don't fit in memory to")
+	dedupFalsePositiveRate := flag.Float64("dedup_false_positive_rate", 0, "With -dedup_max_entries, remember grids in a Bloom filter that wrongly skips new grids at most this often (0 for exact)")
+	minDifferentEntries := flag.Int("min_different_entries", 0, "Only generate grids with at least this many entries that aren't in each earlier grid (0 for no bound)")
+	minDifferentCells := flag.Float64("min_different_cells", 0, "Only generate grids where at least this fraction of cells differs from each earlier grid (0 for no bound)")
+	refill := flag.String("refill", "", "Refill the rectangle x0,y0,x1,y1 (inclusive, 0-based) of the -pattern grid, keeping every letter outside of it")
+	explain := flag.Bool("explain", false, "If the pattern has no fill, explain which of its cells are to blame")
+	sample := flag.Bool("sample", false, "Generate grids at random, each approximately uniformly out of all grids, instead of searching in order. Grids may repeat")
+	sampleCandidates := flag.Int("sample_candidates", 0, "With -sample, the number of random walks to pick each grid from; more is closer to uniform but slower (0 for the default)")
+
+	fixedPassPropagation := flag.Bool("fixed_pass_propagation", false, "Use the original fixed-pass constraint propagation instead of propagating to a fixpoint")
+	checkpointFile := flag.String("checkpoint", "", "A file to save the state of the search to, every -checkpoint_interval and when it stops")
+	checkpointInterval := flag.Duration("checkpoint_interval", time.Minute, "How often to save the state of the search to -checkpoint")
+	resumeFile := flag.String("resume", "", "A file written by -checkpoint to continue the search from. Fails unless the words and options are the same")
+	restartBase := flag.Int("restart_base", 0, "Restart the search with a fresh random ordering after this many failures, growing with the Luby sequence (0 means never)")
+	noConflictLearning := flag.Bool("no_conflict_learning", false, "Disable conflict-directed backjumping and nogood learning")
 
 	timeout := flag.Duration("timeout", 1*time.Minute, "The timeout for the generator")
 
@@ -41,34 +67,20 @@ func main() {
 
 	randSource := rand.NewPCG(uint64(time.Now().UnixNano()), uint64(time.Now().Nanosecond()))
 
-	var preferredWords, obscureWords, excludedWords []string
-	if *file != "" {
-		fmt.Println("Loading words from file...")
-		var err error
-		if preferredWords, err = loadFromFile(ctx, *file, *minWordLength, *sideLength); err != nil {
-			fmt.Println("Error loading words from file:", err)
-			os.Exit(1)
-		}
-	}
-	if *obscureFile != "" {
-		fmt.Println("Loading obscure words from file...")
-		var err error
-		if obscureWords, err = loadFromFile(ctx, *obscureFile, *minWordLength, *sideLength); err != nil {
-			fmt.Println("Error loading obscure words from file:", err)
-			os.Exit(1)
-		}
+	tiers, excludedWords, err := generatorFlags.loadWords(ctx, os.Stdout)
+	if err != nil {
+		fmt.Println("Error", err)
+		os.Exit(1)
 	}
-	if *excludedFile != "" {
-		fmt.Println("Loading excluded words from file...")
-		var err error
-		if excludedWords, err = loadFromFile(ctx, *excludedFile, *minWordLength, *sideLength); err != nil {
-			fmt.Println("Error loading excluded words from file:", err)
-			os.Exit(1)
-		}
+	params, err := generatorFlags.params()
+	if err != nil {
+		fmt.Println("Error", err)
+		os.Exit(1)
 	}
 
-	fmt.Println("Preferred words:", len(preferredWords))
-	fmt.Println("Obscure words:", len(obscureWords))
+	for _, tier := range tiers {
+		fmt.Printf("%s words: %d\n", tier.Name, len(tier.Words))
+	}
 	fmt.Println("Excluded words:", len(excludedWords))
 
 	var mf *os.File
@@ -94,22 +106,100 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
-	grid := xwgen.CreateGenerator(
-		*sideLength,
-		preferredWords,
-		obscureWords,
-		excludedWords,
-		rand.New(randSource),
-		xwgen.GeneratorParams{
-			MinWordLength: 3,
-			MaxWordLength: *sideLength,
-		},
-	)
+	var resume *xwgen.Checkpoint
+	if *resumeFile != "" {
+		if resume, err = readCheckpoint(*resumeFile); err != nil {
+			fmt.Println("Error reading checkpoint:", err)
+			os.Exit(1)
+		}
+	}
+	// saved is the checkpoint in -checkpoint. The files it keeps on disk are removed once a newer
+	// checkpoint replaces it.
+	var saved *xwgen.Checkpoint
+	if *resumeFile == *checkpointFile {
+		saved = resume
+	}
+	saveCheckpoint := func(c *xwgen.Checkpoint) error {
+		if err := writeCheckpoint(*checkpointFile, c); err != nil {
+			c.Remove()
+			return err
+		}
+		if saved != nil {
+			saved.Remove()
+		}
+		saved = c
+		return nil
+	}
+	var onCheckpoint func(*xwgen.Checkpoint)
+	if *checkpointFile != "" {
+		onCheckpoint = func(c *xwgen.Checkpoint) {
+			if err := saveCheckpoint(c); err != nil {
+				fmt.Fprintln(os.Stderr, "Error saving checkpoint:", err)
+			}
+		}
+	}
+
+	params.DedupSymmetries = *dedupSymmetries
+	params.Dedup = xwgen.DedupOptions{
+		MaxEntries:        *dedupMaxEntries,
+		SpillDir:          *dedupSpillDir,
+		FalsePositiveRate: *dedupFalsePositiveRate,
+	}
+	params.Diversity = xwgen.DiversityOptions{
+		MinDifferentEntries: *minDifferentEntries,
+		MinDifferentCells:   *minDifferentCells,
+	}
+	params.SampleCandidates = *sampleCandidates
+	params.FixedPassPropagation = *fixedPassPropagation
+	params.DisableConflictLearning = *noConflictLearning
+	params.RestartBase = *restartBase
+	params.Resume = resume
+	params.OnCheckpoint = onCheckpoint
+	params.CheckpointInterval = *checkpointInterval
+	gen := xwgen.CreateGenerator(*generatorFlags.sideLength, tiers, excludedWords, rand.New(randSource), params)
+	defer gen.Close()
 
 	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
 	defer cancel()
 
-	for grid := range grid.PossibleGrids(ctx) {
+	grids := gen.PossibleGrids(ctx)
+	if *sample {
+		grids = gen.Sample(ctx)
+	}
+	pattern, hasPattern, err := generatorFlags.pattern()
+	if err != nil {
+		fmt.Println("Error", err)
+		os.Exit(1)
+	}
+	if hasPattern {
+		if *refill != "" {
+			if *sample {
+				fmt.Println("-refill can't be combined with -sample")
+				os.Exit(1)
+			}
+			var x0, y0, x1, y1 int
+			if _, err := fmt.Sscanf(*refill, "%d,%d,%d,%d", &x0, &y0, &x1, &y1); err != nil {
+				fmt.Println("Error parsing -refill, expected x0,y0,x1,y1:", err)
+				os.Exit(1)
+			}
+			grids, err = gen.Refill(ctx, pattern, xwgen.Rect(x0, y0, x1, y1))
+		} else if *sample {
+			grids, err = gen.SampleFrom(ctx, pattern)
+		} else {
+			grids, err = gen.PossibleGridsFrom(ctx, pattern)
+		}
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	} else if *refill != "" {
+		fmt.Println("-refill requires -pattern")
+		os.Exit(1)
+	}
+
+	found := false
+	for grid := range grids {
+		found = true
 		if err := ctx.Err(); err != nil {
 			fmt.Println("Context error:", err)
 			break
@@ -141,6 +231,36 @@ func main() {
 
 	fmt.Println("--------------------------------")
 	fmt.Println("Done")
+	if err := gen.Err(); err != nil {
+		fmt.Println("Error:", err)
+	}
+	if *checkpointFile != "" {
+		if c, err := gen.Checkpoint(); err != nil {
+			fmt.Println("Error taking checkpoint:", err)
+		} else if err := saveCheckpoint(c); err != nil {
+			fmt.Println("Error saving checkpoint:", err)
+		} else if !c.Done() {
+			fmt.Printf("Saved the search to %s; continue it with -resume=%s\n", *checkpointFile, *checkpointFile)
+		}
+	}
+
+	if !found && *explain && hasPattern && ctx.Err() == nil {
+		contradiction, err := gen.Explain(ctx, pattern)
+		switch {
+		case err != nil:
+			fmt.Println("Error explaining pattern:", err)
+		case contradiction != nil:
+			fmt.Println("The pattern has no fill because of these cells and entries:")
+			fmt.Println(contradiction)
+		}
+	}
+
+	stats := gen.SearchStats().Propagation
+	fmt.Printf("Propagation: %d propagations, %d revisions, %d narrowings, %d possibilities removed, %d wipeouts\n",
+		stats.Propagations, stats.Revisions, stats.Narrowings, stats.Removed, stats.Wipeouts)
+	conflicts := gen.SearchStats().Conflicts
+	fmt.Printf("Conflicts: %d backjumps, %d nogoods learned, %d nogood hits, %d restarts\n",
+		conflicts.Backjumps, conflicts.NogoodsLearned, conflicts.NogoodHits, conflicts.Restarts)
 
 	if mf != nil {
 		pprof.WriteHeapProfile(mf)
@@ -151,32 +271,73 @@ func main() {
 	}
 }
 
+// loadTiers loads the word-list tiers given by the -file, -obscure and -list flags, reporting
+// progress to out.
+func loadTiers(ctx context.Context, out io.Writer, file, obscureFile string, lists tierFlags, minWordLength, maxWordLength int) ([]xwgen.WordTier, error) {
+	var specs []tierSpec
+	if file != "" {
+		specs = append(specs, tierSpec{name: "preferred", path: file, weight: 1})
+	}
+	if obscureFile != "" {
+		specs = append(specs, tierSpec{name: "obscure", path: obscureFile, weight: 0.5})
+	}
+	specs = append(specs, lists...)
+
+	var tiers []xwgen.WordTier
+	for _, spec := range specs {
+		fmt.Fprintf(out, "Loading %s words from file...\n", spec.name)
+		words, err := loadFromFile(ctx, spec.path, minWordLength, maxWordLength)
+		if err != nil {
+			return nil, fmt.Errorf("loading %s words from file: %w", spec.name, err)
+		}
+		tiers = append(tiers, xwgen.WordTier{
+			Name:       spec.name,
+			Words:      words,
+			Weight:     spec.weight,
+			MaxEntries: spec.maxEntries,
+		})
+	}
+	return tiers, nil
+}
+
 func loadFromFile(ctx context.Context, path string, minWordLength int, maxWordLength int) ([]string, error) {
+	entries, warnings, err := wordlist.LoadFile(ctx, path, wordlist.Options{
+		MinLength: minWordLength,
+		MaxLength: maxWordLength,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", path, w)
+	}
+	return wordlist.Words(entries), nil
+}
+
+// readCheckpoint reads the checkpoint saved in path.
+func readCheckpoint(path string) (*xwgen.Checkpoint, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
+	return xwgen.ReadCheckpoint(f)
+}
 
-	var words []string
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		word := strings.ToLower(strings.TrimSpace(scanner.Text()))
-		if strings.HasPrefix(word, "#") {
-			continue
-		}
-		if len(word) < minWordLength || len(word) > maxWordLength {
-			continue
-		}
-		if ctx.Err() != nil {
-			return nil, ctx.Err()
-		}
-		for _, r := range word {
-			if r < 'a' || r > 'z' {
-				return nil, fmt.Errorf("word %s contains non-lowercase letter %q", word, r)
-			}
-		}
-		words = append(words, word)
+// writeCheckpoint saves c to path, replacing the previous checkpoint only once c is fully written.
+func writeCheckpoint(path string, c *xwgen.Checkpoint) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if err := c.Write(f); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
 	}
-	return words, scanner.Err()
+	return os.Rename(f.Name(), path)
 }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Eyas/xwgen"
+)
+
+// runQuery implements "xwcli query [flags] PATTERN", which lists the words matching a pattern.
+func runQuery(args []string) {
+	fs := flag.NewFlagSet("query", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: xwcli query [flags] [PATTERN]")
+		fmt.Fprintln(fs.Output(), "PATTERN is e.g. 'a?c?e': '?' is any letter, '[abc]' any of the listed letters and '*' any run of letters.")
+		fs.PrintDefaults()
+	}
+	words := addWordFlags(fs)
+	length := fs.Int("length", 0, "Only list words of this length (0 for any)")
+	var contains stringsFlag
+	fs.Var(&contains, "contains", "Only list words containing this substring. Repeatable")
+	limit := fs.Int("limit", 100, "The maximum number of words to list (0 for no limit)")
+	fs.Parse(args)
+
+	if fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	tiers, excludedWords, err := words.load(ctx, os.Stderr, 0, 0)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error", err)
+		os.Exit(1)
+	}
+	params, err := words.params()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error", err)
+		os.Exit(1)
+	}
+
+	gen := xwgen.CreateGenerator(0, tiers, excludedWords, nil, params)
+	results, err := gen.Query(ctx, xwgen.Query{
+		Pattern:  fs.Arg(0),
+		Length:   *length,
+		Contains: contains,
+		Limit:    *limit,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, r := range results {
+		fmt.Printf("%s\t%g\t%s\n", r.Word, r.Score, tiers[r.Tier].Name)
+	}
+}
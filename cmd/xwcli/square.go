@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"time"
+
+	"github.com/Eyas/xwgen"
+)
+
+// runSquare implements "xwcli square [flags]", which generates block-free word squares and word
+// rectangles.
+func runSquare(args []string) {
+	fs := flag.NewFlagSet("square", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: xwcli square [flags]")
+		fmt.Fprintln(fs.Output(), "Generates word squares, where row i is the same word as column i. With -height or -double, generates word rectangles, where every row and column is a different word.")
+		fs.PrintDefaults()
+	}
+	width := fs.Int("width", 4, "The width of the grid")
+	height := fs.Int("height", 0, "The height of the grid, for word rectangles (0 for the width)")
+	double := fs.Bool("double", false, "Generate double word squares, where rows and columns are different words")
+	words := addWordFlags(fs)
+	limit := fs.Int("limit", 1, "The maximum number of grids to generate (0 for no limit)")
+	timeout := fs.Duration("timeout", 1*time.Minute, "The timeout for the generator")
+	fs.Parse(args)
+
+	if *height == 0 {
+		*height = *width
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	tiers, excludedWords, err := words.load(ctx, os.Stderr, 0, 0)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error", err)
+		os.Exit(1)
+	}
+	params, err := words.params()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error", err)
+		os.Exit(1)
+	}
+
+	randSource := rand.NewPCG(uint64(time.Now().UnixNano()), uint64(time.Now().Nanosecond()))
+	gen := xwgen.CreateGenerator(*width, tiers, excludedWords, rand.New(randSource), params)
+	grids := gen.WordSquares(ctx)
+	if *double || *height != *width {
+		grids = gen.WordRectangles(ctx, *width, *height)
+	}
+
+	count := 0
+	for grid := range grids {
+		if count > 0 {
+			fmt.Println()
+		}
+		fmt.Println(grid.Repr())
+		count++
+		if *limit > 0 && count >= *limit {
+			break
+		}
+	}
+	if ctx.Err() != nil {
+		fmt.Fprintln(os.Stderr, "Context error:", ctx.Err())
+		os.Exit(1)
+	}
+	if count == 0 {
+		fmt.Fprintln(os.Stderr, "No grids found")
+		os.Exit(1)
+	}
+}
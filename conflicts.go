@@ -0,0 +1,208 @@
+package xwgen
+
+import (
+	"fmt"
+	"math/bits"
+	"slices"
+
+	"github.com/Eyas/xwgen/pkg/primitives"
+)
+
+// levelSet is an immutable set of decision levels.
+//
+// Every branch in the search is a decision at some level (its depth). Each line in a gridState
+// carries the levels whose decisions narrowed it down, and a failed subtree reports the levels that
+// explain its failure. A node whose own level is not among them can be skipped entirely, along with
+// all of its remaining siblings.
+type levelSet struct {
+	bits []uint64
+}
+
+// levelsUpTo returns the set {0, 1, ..., n-1}.
+func levelsUpTo(n int) levelSet {
+	var s levelSet
+	for i := range n {
+		s = s.with(i)
+	}
+	return s
+}
+
+func (s levelSet) has(level int) bool {
+	w := level / 64
+	return w < len(s.bits) && s.bits[w]&(1<<(level%64)) != 0
+}
+
+func (s levelSet) with(level int) levelSet {
+	if s.has(level) {
+		return s
+	}
+	w := level / 64
+	b := make([]uint64, max(len(s.bits), w+1))
+	copy(b, s.bits)
+	b[w] |= 1 << (level % 64)
+	return levelSet{bits: b}
+}
+
+func (s levelSet) without(level int) levelSet {
+	if !s.has(level) {
+		return s
+	}
+	b := slices.Clone(s.bits)
+	b[level/64] &^= 1 << (level % 64)
+	return levelSet{bits: b}
+}
+
+func (s levelSet) union(other levelSet) levelSet {
+	if len(other.bits) == 0 {
+		return s
+	}
+	if len(s.bits) == 0 {
+		return other
+	}
+	b := make([]uint64, max(len(s.bits), len(other.bits)))
+	copy(b, s.bits)
+	changed := len(other.bits) > len(s.bits)
+	for i, w := range other.bits {
+		if b[i]|w != b[i] {
+			changed = true
+		}
+		b[i] |= w
+	}
+	if !changed {
+		return s
+	}
+	return levelSet{bits: b}
+}
+
+// levels returns the levels in the set, in increasing order.
+func (s levelSet) levels() []int {
+	var levels []int
+	for w, word := range s.bits {
+		for word != 0 {
+			i := bits.TrailingZeros64(word)
+			levels = append(levels, w*64+i)
+			word &^= 1 << i
+		}
+	}
+	return levels
+}
+
+func (s levelSet) String() string {
+	return fmt.Sprint(s.levels())
+}
+
+// decision is the choice made at one level of the search: a line was either narrowed to a subset of
+// its possibilities, or assigned a single concrete line.
+type decision struct {
+	dir   Direction
+	index int
+	// line is the concrete line assigned, or empty if the decision only narrowed the line down.
+	line string
+}
+
+func (d decision) concrete() bool {
+	return d.line != ""
+}
+
+func (d decision) key() string {
+	return fmt.Sprintf("%d:%d:%s", d.dir, d.index, d.line)
+}
+
+// ConflictStats counts the work done by conflict-directed backjumping and nogood learning, as part of
+// SearchStats.
+type ConflictStats struct {
+	// Backjumps is the number of times the search skipped the remaining siblings of a node because
+	// that node's decision played no part in its failures.
+	Backjumps int64
+	// NogoodsLearned is the number of failing combinations of line assignments recorded.
+	NogoodsLearned int64
+	// NogoodHits is the number of search nodes pruned because they contained a recorded nogood.
+	NogoodHits int64
+	// Restarts is the number of times the search was abandoned and started over (see
+	// Generator.RestartBase).
+	Restarts int64
+}
+
+const (
+	// maxNogoodSize bounds the number of assignments in a recorded nogood; larger ones are rarely
+	// matched again.
+	maxNogoodSize = 8
+	// maxNogoods bounds the number of nogoods recorded in a single search.
+	maxNogoods = 1 << 16
+)
+
+// nogoodStore records combinations of line assignments that are known to have no valid fill.
+//
+// Nogoods only depend on the root of the search, so they stay valid across branches and restarts.
+type nogoodStore struct {
+	// byKey maps the key of every assignment to the nogoods that include it.
+	byKey map[string][][]decision
+	count int
+}
+
+func newNogoodStore() *nogoodStore {
+	return &nogoodStore{byKey: make(map[string][][]decision)}
+}
+
+// learn records the decisions at the given levels of path as a nogood, if they are all concrete
+// assignments. It returns true if the nogood was recorded.
+func (n *nogoodStore) learn(path []decision, conflict levelSet) bool {
+	levels := conflict.levels()
+	if len(levels) == 0 || len(levels) > maxNogoodSize || n.count >= maxNogoods {
+		return false
+	}
+	nogood := make([]decision, len(levels))
+	for i, level := range levels {
+		if level >= len(path) || !path[level].concrete() {
+			return false
+		}
+		nogood[i] = path[level]
+	}
+	for _, d := range nogood {
+		n.byKey[d.key()] = append(n.byKey[d.key()], nogood)
+	}
+	n.count++
+	return true
+}
+
+// match returns the reasons for the lines of the first recorded nogood that holds in state, and
+// false if none holds.
+func (n *nogoodStore) match(state *gridState) (levelSet, bool) {
+	if n.count == 0 {
+		return levelSet{}, false
+	}
+
+	definite := make(map[string]levelSet)
+	for dir, lines := range [2][]primitives.PossibleLines{state.across, state.down} {
+		for i, line := range lines {
+			if line.MaxPossibilities() != 1 {
+				continue
+			}
+			l := line.FirstOrNull()
+			if l == nil {
+				continue
+			}
+			d := decision{dir: Direction(dir), index: i, line: lineKey(l)}
+			definite[d.key()] = state.reasons(Direction(dir))[i]
+		}
+	}
+
+	for key := range definite {
+		for _, nogood := range n.byKey[key] {
+			var conflict levelSet
+			holds := true
+			for _, d := range nogood {
+				reasons, ok := definite[d.key()]
+				if !ok {
+					holds = false
+					break
+				}
+				conflict = conflict.union(reasons)
+			}
+			if holds {
+				return conflict, true
+			}
+		}
+	}
+	return levelSet{}, false
+}
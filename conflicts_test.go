@@ -0,0 +1,95 @@
+package xwgen
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/Eyas/xwgen/pkg/primitives"
+)
+
+func TestLevelSet(t *testing.T) {
+	s := levelSet{}.with(3).with(70).with(3)
+	if got, want := s.String(), "[3 70]"; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	if !s.has(70) || s.has(4) || s.has(200) {
+		t.Errorf("unexpected membership in %s", s)
+	}
+
+	u := s.union(levelsUpTo(2))
+	if got, want := u.String(), "[0 1 3 70]"; got != want {
+		t.Errorf("expected union %s, got %s", want, got)
+	}
+	if got, want := u.without(70).without(5).String(), "[0 1 3]"; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	// Sets are immutable.
+	if got, want := s.String(), "[3 70]"; got != want {
+		t.Errorf("expected the original set to stay %s, got %s", want, got)
+	}
+}
+
+func TestNogoodStore(t *testing.T) {
+	words := primitives.MakeWordsFromTiers([][]string{{"abc", "bad", "cab"}}, 3)
+	abc := words.Filter('a', 0).Filter('b', 1)
+	bad := words.Filter('b', 0).Filter('a', 1)
+
+	path := []decision{
+		{dir: DirectionHorizontal, index: 0, line: "abc"},
+		{dir: DirectionVertical, index: 1},
+		{dir: DirectionVertical, index: 2, line: "bad"},
+	}
+	store := newNogoodStore()
+	if store.learn(path, levelSet{}.with(0).with(1)) {
+		t.Error("expected a nogood with a narrowing decision not to be learned")
+	}
+	if !store.learn(path, levelSet{}.with(0).with(2)) {
+		t.Fatal("expected nogood to be learned")
+	}
+
+	state := gridState{
+		across:        []primitives.PossibleLines{abc, words, words},
+		down:          []primitives.PossibleLines{words, words, bad},
+		acrossReasons: []levelSet{levelSet{}.with(4), {}, {}},
+		downReasons:   []levelSet{{}, {}, levelSet{}.with(6)},
+		rand:          rand.New(rand.NewPCG(1, 2)),
+		config:        &searchConfig{propagationStats: &PropagationStats{}},
+	}
+	conflict, ok := store.match(&state)
+	if !ok {
+		t.Fatal("expected the nogood to match")
+	}
+	if got, want := conflict.levels(), []int{4, 6}; !slices.Equal(got, want) {
+		t.Errorf("expected the match to be blamed on %v, got %v", want, got)
+	}
+
+	state.down[2] = words
+	if _, ok := store.match(&state); ok {
+		t.Error("expected no match once an assignment is undone")
+	}
+}
+
+func TestPossibleGrids_ConflictLearningIsSound(t *testing.T) {
+	words := loadWords(t)
+	grids := func(params GeneratorParams) []string {
+		params.MinWordLength = 3
+		gen := CreateGenerator(3, PreferredAndObscureTiers(words, nil), nil, rand.New(rand.NewPCG(1, 2)), params)
+		var reprs []string
+		for grid := range gen.PossibleGrids(context.Background()) {
+			reprs = append(reprs, grid.Repr())
+		}
+		slices.Sort(reprs)
+		return reprs
+	}
+
+	with := grids(GeneratorParams{})
+	without := grids(GeneratorParams{DisableConflictLearning: true})
+	if len(with) == 0 {
+		t.Fatal("expected at least one grid")
+	}
+	if !slices.Equal(with, without) {
+		t.Errorf("conflict learning changed the result: %d grids with it, %d without", len(with), len(without))
+	}
+}
@@ -0,0 +1,190 @@
+package xwgen
+
+import "github.com/Eyas/xwgen/pkg/primitives"
+
+// cellGraph is the graph of the cells of a partial grid that may still hold letters, with edges
+// between neighbouring cells. Regions are measured by the cells that definitely hold letters, so a
+// region that is weakly connected in a partial grid stays so in every grid it leads to.
+type cellGraph struct {
+	width, height int
+	// open[y][x] is false if the cell is definitely a block or was removed as part of a cut.
+	open [][]bool
+	// letter[y][x] is true if the cell definitely holds a letter.
+	letter [][]bool
+}
+
+func newCellGraph(state *gridState) *cellGraph {
+	g := &cellGraph{width: len(state.down), height: len(state.across)}
+	across := make([][]primitives.CharSet, g.height)
+	for y, line := range state.across {
+		across[y] = charsOf(line)
+	}
+	for y := range g.height {
+		open := make([]bool, g.width)
+		letter := make([]bool, g.width)
+		for x := range g.width {
+			var down primitives.CharSet
+			state.down[x].CharsAt(&down, y)
+			blocked := state.across[y].DefinitelyBlockedAt(x) || state.down[x].DefinitelyBlockedAt(y)
+			open[x] = !blocked
+			letter[x] = !blocked && (!across[y][x].Contains(BlockCell) || !down.Contains(BlockCell))
+		}
+		g.open = append(g.open, open)
+		g.letter = append(g.letter, letter)
+	}
+	return g
+}
+
+// neighbours calls f with every open neighbour of the cell at (x, y).
+func (g *cellGraph) neighbours(x, y int, f func(x, y int)) {
+	for _, d := range [4][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+		nx, ny := x+d[0], y+d[1]
+		if nx >= 0 && ny >= 0 && nx < g.width && ny < g.height && g.open[ny][nx] {
+			f(nx, ny)
+		}
+	}
+}
+
+// components labels every open cell with its connected component, starting at 1, and returns the
+// number of letters in each component.
+func (g *cellGraph) components() ([][]int, []int) {
+	labels := make([][]int, g.height)
+	for y := range labels {
+		labels[y] = make([]int, g.width)
+	}
+	letters := []int{0}
+	for y := range g.height {
+		for x := range g.width {
+			if !g.open[y][x] || labels[y][x] != 0 {
+				continue
+			}
+			label := len(letters)
+			letters = append(letters, 0)
+			queue := [][2]int{{x, y}}
+			labels[y][x] = label
+			for len(queue) > 0 {
+				c := queue[0]
+				queue = queue[1:]
+				if g.letter[c[1]][c[0]] {
+					letters[label]++
+				}
+				g.neighbours(c[0], c[1], func(nx, ny int) {
+					if labels[ny][nx] == 0 {
+						labels[ny][nx] = label
+						queue = append(queue, [2]int{nx, ny})
+					}
+				})
+			}
+		}
+	}
+	return labels, letters
+}
+
+// hasCut returns true if removing at most budget open cells separates two regions of at least
+// minRegion letters each.
+func (g *cellGraph) hasCut(budget, minRegion int) bool {
+	_, letters := g.components()
+	big := 0
+	for _, n := range letters[1:] {
+		if n >= minRegion {
+			big++
+		}
+	}
+	if big >= 2 {
+		return true
+	}
+	if budget == 0 {
+		return false
+	}
+	if budget == 1 {
+		return g.hasArticulation(minRegion)
+	}
+
+	for y := range g.height {
+		for x := range g.width {
+			if !g.open[y][x] {
+				continue
+			}
+			g.open[y][x] = false
+			found := g.hasCut(budget-1, minRegion)
+			g.open[y][x] = true
+			if found {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasArticulation returns true if removing a single open cell separates two regions of at least
+// minRegion letters each.
+func (g *cellGraph) hasArticulation(minRegion int) bool {
+	labels, letters := g.components()
+	disc := make([][]int, g.height)
+	low := make([][]int, g.height)
+	for y := range disc {
+		disc[y] = make([]int, g.width)
+		low[y] = make([]int, g.width)
+	}
+	time := 0
+
+	// visit runs Tarjan's DFS from (x, y), and returns the number of letters in its subtree.
+	var found bool
+	var visit func(x, y int, parent [2]int) int
+	visit = func(x, y int, parent [2]int) int {
+		time++
+		disc[y][x], low[y][x] = time, time
+		subtree := 0
+		if g.letter[y][x] {
+			subtree = 1
+		}
+		// Removing (x, y) splits its component into the subtrees cut off below it and the rest.
+		rest, big := letters[labels[y][x]]-subtree, 0
+		g.neighbours(x, y, func(nx, ny int) {
+			if found {
+				return
+			}
+			if disc[ny][nx] == 0 {
+				child := visit(nx, ny, [2]int{x, y})
+				subtree += child
+				low[y][x] = min(low[y][x], low[ny][nx])
+				if low[ny][nx] >= disc[y][x] {
+					rest -= child
+					if child >= minRegion {
+						big++
+					}
+				}
+			} else if [2]int{nx, ny} != parent {
+				low[y][x] = min(low[y][x], disc[ny][nx])
+			}
+		})
+		if rest >= minRegion {
+			big++
+		}
+		if big >= 2 {
+			found = true
+		}
+		return subtree
+	}
+
+	for y := range g.height {
+		for x := range g.width {
+			if g.open[y][x] && disc[y][x] == 0 {
+				visit(x, y, [2]int{-1, -1})
+				if found {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// weaklyConnected returns true if some region of at least minRegion letters is connected to
+// another one through fewer than minConnectivity cells.
+func weaklyConnected(state *gridState, minConnectivity, minRegion int) bool {
+	if minConnectivity <= 0 {
+		return false
+	}
+	return newCellGraph(state).hasCut(minConnectivity-1, max(minRegion, 1))
+}
@@ -0,0 +1,68 @@
+package xwgen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWeaklyConnected(t *testing.T) {
+	open := []string{"aaaaa", "aaaaa", "aaaaa", "aaaaa", "aaaaa"}
+	bridge := []string{"aaa#aaa", "aaa#aaa", "aaaaaaa", "aaa#aaa", "aaa#aaa"}
+	wideBridge := []string{"aaa#aaa", "aaaaaaa", "aaaaaaa", "aaaaaaa", "aaa#aaa"}
+	divided := []string{"aaa#aaa", "aaa#aaa", "aaa#aaa", "aaa#aaa", "aaa#aaa"}
+	// Removing the cell where the arms meet leaves pieces of four, two and two letters.
+	star := []string{"####a##", "####a##", "aaaaaaa"}
+	// Removing the cell where the arms meet leaves pieces of six, two, one and one letters, but
+	// removing the cell to its left leaves two pieces of five.
+	cross := []string{"######a##", "aaaaaaaaa", "######a##"}
+
+	tests := []struct {
+		name            string
+		grid            []string
+		minConnectivity int
+		minRegion       int
+		want            bool
+	}{
+		{name: "open", grid: open, minConnectivity: 2, want: false},
+		{name: "corner cut off", grid: open, minConnectivity: 3, want: true},
+		{name: "corner too small", grid: open, minConnectivity: 3, minRegion: 2, want: false},
+		{name: "bridge", grid: bridge, minConnectivity: 2, minRegion: 4, want: true},
+		{name: "connected", grid: bridge, minConnectivity: 1, minRegion: 4, want: false},
+		{name: "wide bridge", grid: wideBridge, minConnectivity: 3, minRegion: 4, want: false},
+		{name: "narrow wide bridge", grid: wideBridge, minConnectivity: 4, minRegion: 4, want: true},
+		{name: "star", grid: star, minConnectivity: 2, minRegion: 4, want: false},
+		{name: "star with larger arms", grid: star, minConnectivity: 2, minRegion: 2, want: true},
+		{name: "cross", grid: cross, minConnectivity: 2, minRegion: 5, want: true},
+		{name: "cross too small", grid: cross, minConnectivity: 2, minRegion: 6, want: false},
+		{name: "divided", grid: divided, minConnectivity: 1, want: true},
+		{name: "disabled", grid: divided, minConnectivity: 0, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := stateOf(t, mustParseGrid(t, strings.Join(tt.grid, "\n")))
+			if got := weaklyConnected(state, tt.minConnectivity, tt.minRegion); got != tt.want {
+				t.Errorf("weaklyConnected(%d, %d) = %v, want %v", tt.minConnectivity, tt.minRegion, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWeaklyConnected_PartialState(t *testing.T) {
+	words := loadWords(t)
+	gen := CreateGenerator(7, PreferredAndObscureTiers(words, nil), nil, nil, GeneratorParams{MinWordLength: 3})
+	state, err := gen.initialState(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the blocks are known: the left and right halves are joined by the middle cell, which
+	// may or may not be a block, and nothing is known to hold a letter yet.
+	state.applyCells(patternCells(mustParseGrid(t, "???#???\n???#???\n???#???\n???????\n???#???\n???#???\n???#???")))
+
+	if weaklyConnected(&state, 2, 1) {
+		t.Error("expected no region to count before any cell definitely holds a letter")
+	}
+	state.applyCells(patternCells(mustParseGrid(t, "a?????a\n???????\n???????\n???????\n???????\n???????\n???????")))
+	if !weaklyConnected(&state, 2, 1) {
+		t.Error("expected the halves to be joined through a single cell")
+	}
+}
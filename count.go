@@ -0,0 +1,104 @@
+package xwgen
+
+import (
+	"context"
+	"slices"
+)
+
+// GridCount is the number of grids a generator can fill, in total and by block pattern.
+type GridCount struct {
+	Total int64
+	// ByPattern maps the block pattern of the grids, i.e. the Repr of a grid with every letter
+	// replaced by OpenCell, to the number of grids with that pattern.
+	ByPattern map[string]int64
+}
+
+// Count counts the grids that PossibleGrids would yield, by exploring the whole search space
+// without building them. Rotations and reflections of a grid are counted separately, even with
+// DedupSymmetries, and RestartBase and Resume are ignored.
+//
+// If ctx is done before the count ends, Count returns the grids counted so far along with the
+// context's error.
+func (g *Generator) Count(ctx context.Context) (GridCount, error) {
+	return g.count(ctx, func(*gridState) {})
+}
+
+// CountFrom counts the grids that fill the given pattern, like Count. It returns an error if the
+// pattern doesn't match the generator's grid size.
+func (g *Generator) CountFrom(ctx context.Context, pattern Grid) (GridCount, error) {
+	if err := checkPattern(pattern, g.LineLength); err != nil {
+		return GridCount{}, err
+	}
+	cells := patternCells(pattern)
+	return g.count(ctx, func(s *gridState) { s.applyCells(cells) })
+}
+
+// count counts the grids reachable from the initial state, once narrowed down by setup.
+func (g *Generator) count(ctx context.Context, setup func(*gridState)) (GridCount, error) {
+	gs, err := g.initialState(ctx)
+	if err != nil {
+		return GridCount{}, err
+	}
+	setup(&gs)
+
+	progress := gs.config.progress
+	defer func() {
+		g.searchStats = progress.finish()
+	}()
+
+	// Patterns are looked up by their bytes, which doesn't allocate, and counted through pointers so
+	// that only new patterns are converted to strings.
+	byPattern := make(map[string]*int64)
+	var key []byte
+	possibleGridsAtRoot(ctx, &gs, func(f fill) bool {
+		progress.stats.Grids++
+		key = f.appendPattern(key[:0])
+		n := byPattern[string(key)]
+		if n == nil {
+			n = new(int64)
+			byPattern[string(key)] = n
+		}
+		*n++
+		return true
+	})
+
+	count := GridCount{Total: progress.stats.Grids, ByPattern: make(map[string]int64, len(byPattern))}
+	for pattern, n := range byPattern {
+		count.ByPattern[pattern] = *n
+	}
+	return count, ctx.Err()
+}
+
+// appendPattern appends the block pattern of the fill to b, in the format of Grid.Repr.
+func (f fill) appendPattern(b []byte) []byte {
+	for y, row := range f.across {
+		if y > 0 {
+			b = append(b, '\n')
+		}
+		for x, r := range row.Line {
+			if x > 0 && f.barred {
+				b = append(b, byte(barRune(slices.Contains(row.Bars, x), '|')))
+			}
+			if r == BlockCell {
+				b = append(b, BlockCell)
+			} else {
+				b = append(b, OpenCell)
+			}
+		}
+		if !f.barred || y+1 == len(f.across) {
+			continue
+		}
+		// The bars below the row are those before row y+1 in the columns.
+		b = append(b, '\n')
+		for x, column := range f.down {
+			if x > 0 {
+				b = append(b, ' ')
+			}
+			b = append(b, byte(barRune(slices.Contains(column.Bars, y+1), '-')))
+		}
+		for b[len(b)-1] == ' ' {
+			b = b[:len(b)-1]
+		}
+	}
+	return b
+}
@@ -0,0 +1,94 @@
+package xwgen
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// blockPattern returns the Repr of grid with every letter replaced by OpenCell.
+func blockPattern(grid Grid) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return OpenCell
+		}
+		return r
+	}, grid.Repr())
+}
+
+func TestCount(t *testing.T) {
+	words := loadWords(t)
+	tests := []struct {
+		name    string
+		params  GeneratorParams
+		pattern string
+	}{
+		{
+			name:    "pattern",
+			params:  GeneratorParams{MinWordLength: 3},
+			pattern: strings.Join([]string{"...`", "....", "....", "`..."}, "\n"),
+		},
+		{
+			// A row with blocks at both ends counts once, not once for each way to build it from a
+			// shorter line.
+			name:    "edge blocks",
+			params:  GeneratorParams{MinWordLength: 3},
+			pattern: strings.Join([]string{"`...`", ".....", "..t..", ".....", "`...`"}, "\n"),
+		},
+		{
+			name:    "open pattern",
+			params:  GeneratorParams{MinWordLength: 3},
+			pattern: strings.Join([]string{"????", "????", "t???", "????"}, "\n"),
+		},
+		{
+			name:    "barred",
+			params:  GeneratorParams{MinWordLength: 3, Barred: true},
+			pattern: strings.Join([]string{"......", "camgal", "......", "tornos", "abscds", "played"}, "\n"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern := mustParseGrid(t, tt.pattern)
+			newGenerator := func() *Generator {
+				return CreateGenerator(pattern.Width(), PreferredAndObscureTiers(words, nil), nil, rand.New(rand.NewPCG(1, 2)), tt.params)
+			}
+
+			want := GridCount{ByPattern: make(map[string]int64)}
+			grids, err := newGenerator().PossibleGridsFrom(context.Background(), pattern)
+			if err != nil {
+				t.Fatalf("PossibleGridsFrom() = %v", err)
+			}
+			for grid := range grids {
+				want.Total++
+				want.ByPattern[blockPattern(grid)]++
+			}
+			if want.Total == 0 {
+				t.Fatal("PossibleGridsFrom() yielded no grids, want some to count")
+			}
+
+			gen := newGenerator()
+			got, err := gen.CountFrom(context.Background(), pattern)
+			if err != nil {
+				t.Fatalf("CountFrom() = %v", err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("CountFrom() mismatch (-want +got):\n%s", diff)
+			}
+			if gen.SearchStats().Grids != got.Total {
+				t.Errorf("SearchStats().Grids = %d, want %d", gen.SearchStats().Grids, got.Total)
+			}
+		})
+	}
+}
+
+func TestCount_Canceled(t *testing.T) {
+	gen := CreateGenerator(4, PreferredAndObscureTiers(loadWords(t), nil), nil, rand.New(rand.NewPCG(1, 2)), GeneratorParams{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := gen.Count(ctx); err != context.Canceled {
+		t.Errorf("Count() with a canceled context = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,393 @@
+package xwgen
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// DedupOptions bounds the memory PossibleGrids uses to remember the grids it has already yielded.
+// Grids are remembered by a 64-bit hash of their Repr, in every mode, so a new grid is mistaken for
+// one already yielded, and skipped, with a probability of about n²/2⁶⁵ after n grids, i.e. less
+// than one in a million for a few million grids.
+type DedupOptions struct {
+	// MaxEntries is the number of hashes kept in memory. Zero means no bound.
+	//
+	// Once the bound is reached, the oldest hashes are forgotten, so a grid found again after that
+	// may be yielded twice, unless SpillDir or FalsePositiveRate is set.
+	MaxEntries int
+	// SpillDir, if set, is a directory where hashes beyond MaxEntries are written, in a single
+	// sorted file that is looked up with a binary search. The file is removed by Generator.Close,
+	// or when the generator starts another search.
+	SpillDir string
+	// FalsePositiveRate, if set, remembers the grids in a Bloom filter sized for MaxEntries grids,
+	// using about 1.44·log₂(1/FalsePositiveRate) bits per grid, e.g. 10 bits for a rate of 1%.
+	// Until MaxEntries grids are remembered, a new grid is wrongly taken for one already yielded,
+	// and skipped, with a probability of at most FalsePositiveRate. The rate grows past that.
+	FalsePositiveRate float64
+}
+
+// seenSet is a set of grid hashes.
+type seenSet interface {
+	// add adds h to the set, and returns false if it was already there.
+	add(h uint64) (bool, error)
+	// close releases the resources held by the set.
+	close() error
+	// snapshot returns the contents of the set, for a Checkpoint.
+	snapshot() (seenSnapshot, error)
+}
+
+// seenSnapshot is the contents of a seenSet: the hashes it holds in memory, oldest first, with a
+// copy of the file it spilled the others to, or the bits of a Bloom filter.
+type seenSnapshot struct {
+	Hashes    []uint64
+	SpillFile string
+	Bits      []uint64
+	K         int
+}
+
+// restoreSeenSet returns a set that follows opts, holding the contents of snapshot.
+func restoreSeenSet(opts DedupOptions, snapshot seenSnapshot) (seenSet, error) {
+	seen, err := newSeenSet(opts)
+	if err != nil {
+		return nil, err
+	}
+	if bloom, ok := seen.(*bloomSet); ok {
+		if len(snapshot.Hashes) > 0 || len(snapshot.Bits) != len(bloom.bits) || snapshot.K != bloom.k {
+			seen.close()
+			return nil, errors.New("the checkpoint's grids weren't remembered with the same FalsePositiveRate and MaxEntries")
+		}
+		copy(bloom.bits, snapshot.Bits)
+		return bloom, nil
+	}
+	if len(snapshot.Bits) > 0 {
+		return nil, errors.New("the checkpoint's grids were remembered with a FalsePositiveRate")
+	}
+	if snapshot.SpillFile != "" {
+		spill, ok := seen.(*spillSet)
+		if !ok {
+			seen.close()
+			return nil, errors.New("the checkpoint's grids were spilled to disk, but SpillDir isn't set")
+		}
+		if err := spill.load(snapshot.SpillFile); err != nil {
+			seen.close()
+			return nil, err
+		}
+	}
+	// Only the hashes that were in memory are listed, so adding them back doesn't spill.
+	for _, h := range snapshot.Hashes {
+		if _, err := seen.add(h); err != nil {
+			seen.close()
+			return nil, err
+		}
+	}
+	return seen, nil
+}
+
+// gridHash returns the hash a grid is remembered by, given its Repr.
+func gridHash(repr string) uint64 {
+	h := fnv.New64a()
+	io.WriteString(h, repr)
+	return h.Sum64()
+}
+
+// newSeenSet returns an empty set that follows opts.
+func newSeenSet(opts DedupOptions) (seenSet, error) {
+	switch {
+	case opts.MaxEntries < 0:
+		return nil, fmt.Errorf("negative MaxEntries %d", opts.MaxEntries)
+	case opts.FalsePositiveRate < 0 || opts.FalsePositiveRate >= 1:
+		return nil, fmt.Errorf("FalsePositiveRate %g is not in [0, 1)", opts.FalsePositiveRate)
+	case opts.FalsePositiveRate > 0 && opts.SpillDir != "":
+		return nil, errors.New("FalsePositiveRate and SpillDir can't be used together")
+	case opts.FalsePositiveRate > 0:
+		if opts.MaxEntries == 0 {
+			return nil, errors.New("FalsePositiveRate needs MaxEntries")
+		}
+		return newBloomSet(opts.MaxEntries, opts.FalsePositiveRate), nil
+	case opts.SpillDir != "":
+		if opts.MaxEntries == 0 {
+			return nil, errors.New("SpillDir needs MaxEntries")
+		}
+		return &spillSet{memory: make(map[uint64]bool), limit: opts.MaxEntries, dir: opts.SpillDir}, nil
+	default:
+		return &memorySet{hashes: make(map[uint64]bool), limit: opts.MaxEntries}, nil
+	}
+}
+
+// memorySet keeps hashes in memory, forgetting the oldest ones beyond limit, if set.
+type memorySet struct {
+	hashes map[uint64]bool
+	limit  int
+	// order holds the hashes in the order they were added, as a ring buffer starting at next once
+	// it is full. It is only kept if there is a limit.
+	order []uint64
+	next  int
+}
+
+func (s *memorySet) add(h uint64) (bool, error) {
+	if s.hashes[h] {
+		return false, nil
+	}
+	s.hashes[h] = true
+	if s.limit == 0 {
+		return true, nil
+	}
+	if len(s.order) < s.limit {
+		s.order = append(s.order, h)
+		return true, nil
+	}
+	delete(s.hashes, s.order[s.next])
+	s.order[s.next] = h
+	s.next = (s.next + 1) % s.limit
+	return true, nil
+}
+
+func (s *memorySet) close() error {
+	return nil
+}
+
+func (s *memorySet) snapshot() (seenSnapshot, error) {
+	if s.limit == 0 {
+		return seenSnapshot{Hashes: slices.Collect(maps.Keys(s.hashes))}, nil
+	}
+	return seenSnapshot{Hashes: slices.Concat(s.order[s.next:], s.order[:s.next])}, nil
+}
+
+// spillSet keeps up to limit hashes in memory, and merges them into a sorted file on disk whenever
+// there are more.
+type spillSet struct {
+	memory map[uint64]bool
+	limit  int
+	dir    string
+	// file holds size sorted hashes, as big-endian uint64s. It is nil until the first spill.
+	file *os.File
+	size int64
+}
+
+func (s *spillSet) add(h uint64) (bool, error) {
+	if s.memory[h] {
+		return false, nil
+	}
+	if found, err := s.onDisk(h); err != nil || found {
+		return false, err
+	}
+	s.memory[h] = true
+	if len(s.memory) > s.limit {
+		if err := s.spill(); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// onDisk returns true if h is in the file.
+func (s *spillSet) onDisk(h uint64) (bool, error) {
+	var buf [8]byte
+	lo, hi := int64(0), s.size
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if _, err := s.file.ReadAt(buf[:], mid*8); err != nil {
+			return false, fmt.Errorf("reading spilled grid hashes: %w", err)
+		}
+		switch v := binary.BigEndian.Uint64(buf[:]); {
+		case v == h:
+			return true, nil
+		case v < h:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+	return false, nil
+}
+
+// spill merges the hashes in memory with those in the file into a new file.
+func (s *spillSet) spill() error {
+	hashes := make([]uint64, 0, len(s.memory))
+	for h := range s.memory {
+		hashes = append(hashes, h)
+	}
+	slices.Sort(hashes)
+
+	merged, err := os.CreateTemp(s.dir, "xwgen-seen-*")
+	if err != nil {
+		return fmt.Errorf("spilling grid hashes: %w", err)
+	}
+	w := bufio.NewWriter(merged)
+	var buf [8]byte
+	write := func(h uint64) error {
+		binary.BigEndian.PutUint64(buf[:], h)
+		_, err := w.Write(buf[:])
+		return err
+	}
+
+	var r *bufio.Reader
+	if s.file != nil {
+		r = bufio.NewReader(io.NewSectionReader(s.file, 0, s.size*8))
+	}
+	next := func() (uint64, bool, error) {
+		if r == nil {
+			return 0, false, nil
+		}
+		if _, err := io.ReadFull(r, buf[:]); err == io.EOF {
+			return 0, false, nil
+		} else if err != nil {
+			return 0, false, err
+		}
+		return binary.BigEndian.Uint64(buf[:]), true, nil
+	}
+
+	err = func() error {
+		old, ok, err := next()
+		for err == nil && (ok || len(hashes) > 0) {
+			if ok && (len(hashes) == 0 || old < hashes[0]) {
+				if err = write(old); err == nil {
+					old, ok, err = next()
+				}
+				continue
+			}
+			err = write(hashes[0])
+			hashes = hashes[1:]
+		}
+		if err != nil {
+			return err
+		}
+		return w.Flush()
+	}()
+	if err != nil {
+		merged.Close()
+		os.Remove(merged.Name())
+		return fmt.Errorf("spilling grid hashes: %w", err)
+	}
+
+	if err := s.removeFile(); err != nil {
+		return err
+	}
+	s.file = merged
+	s.size += int64(len(s.memory))
+	clear(s.memory)
+	return nil
+}
+
+// snapshot copies the file, which the set removes on its next spill, so that the checkpoint keeps
+// the hashes in it for as long as it needs them.
+func (s *spillSet) snapshot() (seenSnapshot, error) {
+	snapshot := seenSnapshot{Hashes: slices.Collect(maps.Keys(s.memory))}
+	if s.file == nil {
+		return snapshot, nil
+	}
+	saved, _, err := copyHashes(io.NewSectionReader(s.file, 0, s.size*8), s.dir, "xwgen-checkpoint-*")
+	if err != nil {
+		return seenSnapshot{}, fmt.Errorf("saving spilled grid hashes: %w", err)
+	}
+	if err := saved.Close(); err != nil {
+		os.Remove(saved.Name())
+		return seenSnapshot{}, fmt.Errorf("saving spilled grid hashes: %w", err)
+	}
+	if snapshot.SpillFile, err = filepath.Abs(saved.Name()); err != nil {
+		os.Remove(saved.Name())
+		return seenSnapshot{}, err
+	}
+	return snapshot, nil
+}
+
+// load copies the hashes a checkpoint spilled into the set's own file, leaving the checkpoint's in
+// place.
+func (s *spillSet) load(name string) error {
+	saved, err := os.Open(name)
+	if err != nil {
+		return fmt.Errorf("reading spilled grid hashes: %w", err)
+	}
+	defer saved.Close()
+	file, size, err := copyHashes(saved, s.dir, "xwgen-seen-*")
+	if err != nil {
+		return fmt.Errorf("reading spilled grid hashes: %w", err)
+	}
+	if err := s.removeFile(); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	s.file, s.size = file, size
+	return nil
+}
+
+// copyHashes copies the sorted hashes read from r to a new file in dir, and returns it with the
+// number of hashes in it.
+func copyHashes(r io.Reader, dir, pattern string) (*os.File, int64, error) {
+	file, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return nil, 0, err
+	}
+	n, err := io.Copy(file, r)
+	if err == nil && n%8 != 0 {
+		err = errors.New("truncated file")
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, 0, err
+	}
+	return file, n / 8, nil
+}
+
+func (s *spillSet) close() error {
+	return s.removeFile()
+}
+
+// removeFile closes and removes the file, if any.
+func (s *spillSet) removeFile() error {
+	if s.file == nil {
+		return nil
+	}
+	s.file.Close()
+	err := os.Remove(s.file.Name())
+	s.file = nil
+	return err
+}
+
+// bloomSet is a Bloom filter: each hash sets k bits, and a hash is taken to be in the set if all
+// of its bits are set.
+type bloomSet struct {
+	bits []uint64
+	k    int
+}
+
+// newBloomSet returns a Bloom filter with a false-positive rate of p once it holds n hashes.
+func newBloomSet(n int, p float64) *bloomSet {
+	m := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	k := max(1, int(math.Round(m/float64(n)*math.Ln2)))
+	return &bloomSet{bits: make([]uint64, (int(m)+63)/64), k: k}
+}
+
+func (s *bloomSet) add(h uint64) (bool, error) {
+	// Derive the k bit indexes from the two halves of h (Kirsch and Mitzenmacher).
+	m := uint64(len(s.bits)) * 64
+	h1, h2 := h&math.MaxUint32, h>>32|1
+	added := false
+	for i := range uint64(s.k) {
+		bit := (h1 + i*h2) % m
+		if s.bits[bit/64]&(1<<(bit%64)) == 0 {
+			s.bits[bit/64] |= 1 << (bit % 64)
+			added = true
+		}
+	}
+	return added, nil
+}
+
+func (s *bloomSet) close() error {
+	return nil
+}
+
+func (s *bloomSet) snapshot() (seenSnapshot, error) {
+	return seenSnapshot{Bits: slices.Clone(s.bits), K: s.k}, nil
+}
@@ -0,0 +1,141 @@
+package xwgen
+
+import (
+	"context"
+	"math/rand/v2"
+	"os"
+	"testing"
+)
+
+func TestSeenSet(t *testing.T) {
+	tests := []struct {
+		name string
+		opts DedupOptions
+	}{
+		{name: "unbounded"},
+		{name: "spill", opts: DedupOptions{MaxEntries: 10, SpillDir: t.TempDir()}},
+		{name: "bloom", opts: DedupOptions{MaxEntries: 1000, FalsePositiveRate: 0.001}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen, err := newSeenSet(tt.opts)
+			if err != nil {
+				t.Fatalf("newSeenSet(%+v) = %v", tt.opts, err)
+			}
+			defer seen.close()
+
+			rng := rand.New(rand.NewPCG(1, 2))
+			hashes := make([]uint64, 100)
+			for i := range hashes {
+				hashes[i] = rng.Uint64()
+				if added, err := seen.add(hashes[i]); err != nil || !added {
+					t.Fatalf("add(%d) = %v, %v, want true", i, added, err)
+				}
+			}
+			for i, h := range hashes {
+				if added, err := seen.add(h); err != nil || added {
+					t.Errorf("add(%d) again = %v, %v, want false", i, added, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSeenSet_MaxEntries(t *testing.T) {
+	seen, err := newSeenSet(DedupOptions{MaxEntries: 2})
+	if err != nil {
+		t.Fatalf("newSeenSet() = %v", err)
+	}
+	for _, h := range []uint64{1, 2, 3} {
+		seen.add(h)
+	}
+	if added, _ := seen.add(3); added {
+		t.Error("add(3) = true, want the latest hash to be remembered")
+	}
+	if added, _ := seen.add(1); !added {
+		t.Error("add(1) = false, want the oldest hash to be forgotten")
+	}
+}
+
+func TestSeenSet_Spill(t *testing.T) {
+	dir := t.TempDir()
+	seen, err := newSeenSet(DedupOptions{MaxEntries: 3, SpillDir: dir})
+	if err != nil {
+		t.Fatalf("newSeenSet() = %v", err)
+	}
+	for h := range uint64(10) {
+		seen.add(h * 7)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 1 {
+		t.Errorf("got %d spill files, want 1", len(files))
+	}
+	if err := seen.close(); err != nil {
+		t.Errorf("close() = %v", err)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("got %d spill files after close, want 0", len(files))
+	}
+}
+
+func TestSeenSet_BloomFalsePositiveRate(t *testing.T) {
+	const n, rate = 10000, 0.01
+	seen, err := newSeenSet(DedupOptions{MaxEntries: n, FalsePositiveRate: rate})
+	if err != nil {
+		t.Fatalf("newSeenSet() = %v", err)
+	}
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range n {
+		seen.add(rng.Uint64())
+	}
+	falsePositives := 0
+	const probes = n / 10
+	for range probes {
+		if added, _ := seen.add(rng.Uint64()); !added {
+			falsePositives++
+		}
+	}
+	// The filter fills up a little as the probes are added, so allow for some slack.
+	if got := float64(falsePositives) / probes; got > 2*rate {
+		t.Errorf("false-positive rate = %g, want about %g", got, rate)
+	}
+}
+
+func TestNewSeenSet_Invalid(t *testing.T) {
+	for _, opts := range []DedupOptions{
+		{MaxEntries: -1},
+		{FalsePositiveRate: 0.01},
+		{FalsePositiveRate: 1, MaxEntries: 10},
+		{SpillDir: "/tmp"},
+		{MaxEntries: 10, FalsePositiveRate: 0.01, SpillDir: "/tmp"},
+	} {
+		if _, err := newSeenSet(opts); err == nil {
+			t.Errorf("newSeenSet(%+v) = nil error, want an error", opts)
+		}
+	}
+}
+
+func TestPossibleGrids_DedupSpill(t *testing.T) {
+	words := loadWords(t)
+	gen := CreateGenerator(4, PreferredAndObscureTiers(words, nil), nil, rand.New(rand.NewPCG(1, 2)), GeneratorParams{
+		MinWordLength: 3,
+		RestartBase:   20,
+		Dedup:         DedupOptions{MaxEntries: 5, SpillDir: t.TempDir()},
+	})
+
+	seen := make(map[string]bool)
+	for grid := range gen.PossibleGrids(context.Background()) {
+		if seen[grid.Repr()] {
+			t.Errorf("grid yielded twice:\n%s", grid.Repr())
+		}
+		seen[grid.Repr()] = true
+		if len(seen) >= 50 {
+			break
+		}
+	}
+	if err := gen.Err(); err != nil {
+		t.Errorf("Err() = %v", err)
+	}
+	if len(seen) < 50 {
+		t.Errorf("got %d grids, want 50", len(seen))
+	}
+}
@@ -0,0 +1,82 @@
+package xwgen
+
+import "github.com/Eyas/xwgen/pkg/primitives"
+
+// DiversityOptions makes PossibleGrids yield only grids that differ enough from every grid it
+// yielded before. Zero values mean no bound. With diversity, the search starts over from the root
+// after each grid.
+type DiversityOptions struct {
+	// MinDifferentEntries is the number of entries of a grid that must not be entries of the earlier
+	// grid.
+	MinDifferentEntries int
+	// MinDifferentCells is the fraction of cells, between 0 and 1, that must hold a different letter
+	// or block than in the earlier grid.
+	MinDifferentCells float64
+}
+
+func (o DiversityOptions) enabled() bool {
+	return o.MinDifferentEntries > 0 || o.MinDifferentCells > 0
+}
+
+// diverseSet holds the grids yielded so far by a search with DiversityOptions.
+type diverseSet struct {
+	opts  DiversityOptions
+	grids []diverseGrid
+}
+
+// diverseGrid is what a diverseSet keeps of a grid: its entries and its cells, row by row.
+type diverseGrid struct {
+	entries map[string]bool
+	cells   []rune
+}
+
+func newDiverseGrid(f fill) diverseGrid {
+	d := diverseGrid{entries: make(map[string]bool)}
+	for _, lines := range [][]*primitives.ConcreteLine{f.across, f.down} {
+		for _, line := range lines {
+			for _, word := range line.Words {
+				d.entries[word] = true
+			}
+		}
+	}
+	for _, row := range f.across {
+		d.cells = append(d.cells, row.Line...)
+	}
+	return d
+}
+
+// differs returns true if d differs enough from every grid in the set.
+func (s *diverseSet) differs(d diverseGrid) bool {
+	for _, other := range s.grids {
+		if s.opts.MinDifferentEntries > 0 && countNew(d.entries, other.entries) < s.opts.MinDifferentEntries {
+			return false
+		}
+		if s.opts.MinDifferentCells > 0 && differentCells(d.cells, other.cells) < s.opts.MinDifferentCells {
+			return false
+		}
+	}
+	return true
+}
+
+// countNew returns the number of entries that aren't in other.
+func countNew(entries, other map[string]bool) int {
+	n := 0
+	for entry := range entries {
+		if !other[entry] {
+			n++
+		}
+	}
+	return n
+}
+
+// differentCells returns the fraction of cells that differ between a and b, which have the same
+// size.
+func differentCells(a, b []rune) float64 {
+	n := 0
+	for i := range a {
+		if a[i] != b[i] {
+			n++
+		}
+	}
+	return float64(n) / float64(len(a))
+}
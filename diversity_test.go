@@ -0,0 +1,115 @@
+package xwgen
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/Eyas/xwgen/pkg/primitives"
+)
+
+// fillOf returns grid as a fill, with the entries of its rows and columns.
+func fillOf(grid Grid) fill {
+	var f fill
+	for y := range grid.Height() {
+		line := &primitives.ConcreteLine{}
+		for x := range grid.Width() {
+			line.Line = append(line.Line, grid.Get(x, y))
+		}
+		line.Words = wordsOf(line.Line)
+		f.across = append(f.across, line)
+	}
+	for x := range grid.Width() {
+		line := &primitives.ConcreteLine{}
+		for y := range grid.Height() {
+			line.Line = append(line.Line, grid.Get(x, y))
+		}
+		line.Words = wordsOf(line.Line)
+		f.down = append(f.down, line)
+	}
+	return f
+}
+
+// wordsOf returns the runs of letters of line, ended by blocks.
+func wordsOf(line []rune) []string {
+	return strings.FieldsFunc(string(line), func(r rune) bool { return r == BlockCell })
+}
+
+func TestDiversity(t *testing.T) {
+	words := loadWords(t)
+	pattern := mustParseGrid(t, strings.Join([]string{"s???", "????", "????", "????"}, "\n"))
+	tests := []struct {
+		name string
+		opts DiversityOptions
+	}{
+		{name: "entries", opts: DiversityOptions{MinDifferentEntries: 6}},
+		{name: "cells", opts: DiversityOptions{MinDifferentCells: 0.75}},
+		{name: "both", opts: DiversityOptions{MinDifferentEntries: 4, MinDifferentCells: 0.5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newGenerator := func(params GeneratorParams) *Generator {
+				params.MinWordLength = 3
+				return CreateGenerator(4, PreferredAndObscureTiers(words, nil), nil, rand.New(rand.NewPCG(1, 2)), params)
+			}
+
+			gen := newGenerator(GeneratorParams{Diversity: tt.opts})
+			grids, err := gen.PossibleGridsFrom(context.Background(), pattern)
+			if err != nil {
+				t.Fatalf("PossibleGridsFrom() = %v", err)
+			}
+			yielded := &diverseSet{opts: tt.opts}
+			for grid := range grids {
+				d := newDiverseGrid(fillOf(grid))
+				if !yielded.differs(d) {
+					t.Fatalf("yielded a grid too close to an earlier one:\n%s", grid.Repr())
+				}
+				yielded.grids = append(yielded.grids, d)
+			}
+			if err := gen.Err(); err != nil {
+				t.Fatalf("Err() = %v", err)
+			}
+			if len(yielded.grids) < 2 {
+				t.Fatalf("yielded %d grids, want several", len(yielded.grids))
+			}
+
+			// The search only ends once every other grid is too close to one that was yielded.
+			all, err := newGenerator(GeneratorParams{}).PossibleGridsFrom(context.Background(), pattern)
+			if err != nil {
+				t.Fatalf("PossibleGridsFrom() = %v", err)
+			}
+			for grid := range all {
+				if yielded.differs(newDiverseGrid(fillOf(grid))) {
+					t.Fatalf("skipped a grid that differs from every yielded grid:\n%s", grid.Repr())
+				}
+			}
+		})
+	}
+}
+
+func TestDiversity_Checkpoint(t *testing.T) {
+	gen := CreateGenerator(4, PreferredAndObscureTiers(loadWords(t), nil), nil, rand.New(rand.NewPCG(1, 2)), GeneratorParams{
+		Diversity: DiversityOptions{MinDifferentEntries: 2},
+	})
+	for range gen.PossibleGrids(context.Background()) {
+		break
+	}
+	if _, err := gen.Checkpoint(); err == nil {
+		t.Error("Checkpoint() of a search with diversity = nil error, want an error")
+	}
+}
+
+func TestDifferentCells(t *testing.T) {
+	a, b := "ab`d", "abxy"
+	if got := differentCells([]rune(a), []rune(b)); got != 0.5 {
+		t.Errorf("differentCells(%q, %q) = %v, want 0.5", a, b, got)
+	}
+}
+
+func TestCountNew(t *testing.T) {
+	entries, other := map[string]bool{"abc": true, "def": true}, map[string]bool{"abc": true, "ghi": true}
+	if got := countNew(entries, other); got != 1 {
+		t.Errorf("countNew(%v, %v) = %d, want 1", entries, other, got)
+	}
+}
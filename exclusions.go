@@ -0,0 +1,50 @@
+package xwgen
+
+import (
+	"regexp"
+	"strings"
+)
+
+// GlobPattern compiles a shell-style glob into a pattern that matches entire words, for use in
+// Generator.ExcludedPatterns.
+//
+// '*' matches any run of letters, '?' matches a single letter, and '[...]' matches a single letter
+// from a class (e.g. "[aeiou]"). Everything else matches itself.
+func GlobPattern(glob string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	inClass := false
+	for _, r := range glob {
+		switch {
+		case inClass:
+			if r == ']' {
+				inClass = false
+			}
+			b.WriteRune(r)
+		case r == '*':
+			b.WriteString(".*")
+		case r == '?':
+			b.WriteString(".")
+		case r == '[':
+			inClass = true
+			b.WriteRune(r)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// hasNestedEntry returns true if any of the given words contains another one, e.g. "parts" and
+// "art".
+func hasNestedEntry(words map[string]bool) bool {
+	for outer := range words {
+		for inner := range words {
+			if len(inner) < len(outer) && strings.Contains(outer, inner) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package xwgen
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestGlobPattern(t *testing.T) {
+	for _, tc := range []struct {
+		glob    string
+		matches []string
+		misses  []string
+	}{
+		{glob: "a?c", matches: []string{"abc", "axc"}, misses: []string{"ac", "abbc", "xabc"}},
+		{glob: "*ing", matches: []string{"ing", "sing", "bring"}, misses: []string{"singe"}},
+		{glob: "[aeiou]*[aeiou]", matches: []string{"area", "oboe"}, misses: []string{"tree", "bear"}},
+		{glob: "a.b", matches: []string{"a.b"}, misses: []string{"axb"}},
+	} {
+		t.Run(tc.glob, func(t *testing.T) {
+			re, err := GlobPattern(tc.glob)
+			if err != nil {
+				t.Fatalf("GlobPattern(%q) returned error: %v", tc.glob, err)
+			}
+			for _, m := range tc.matches {
+				if !re.MatchString(m) {
+					t.Errorf("GlobPattern(%q) should match %q", tc.glob, m)
+				}
+			}
+			for _, m := range tc.misses {
+				if re.MatchString(m) {
+					t.Errorf("GlobPattern(%q) should not match %q", tc.glob, m)
+				}
+			}
+		})
+	}
+}
+
+func TestPossibleGrids_Exclusions(t *testing.T) {
+	// The words make six 4x4 grids, as three pairs of a grid and its transposition. Two pairs have an
+	// entry nested in another: "tar" in "star", and "tat" in "stat".
+	words := []string{"acid", "amal", "caro", "cat", "erie", "mat", "mute", "pst", "puma", "race", "res", "sod", "star", "stat", "tar", "tat", "tee", "tel"}
+	race := []string{joinRows("`cat", "race", "erie", "sod`"), joinRows("`res", "caro", "acid", "tee`")}
+	star := []string{joinRows("`mat", "puma", "star", "tel`"), joinRows("`pst", "mute", "amal", "tar`")}
+	stat := []string{joinRows("`mat", "puma", "stat", "tel`"), joinRows("`pst", "mute", "amal", "tat`")}
+	glob, err := GlobPattern("*r")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		params GeneratorParams
+		want   []string
+	}{
+		{name: "none", want: slices.Concat(race, star, stat)},
+		{name: "pattern", params: GeneratorParams{ExcludedPatterns: []*regexp.Regexp{glob}}, want: slices.Concat(race, stat)},
+		{name: "substring", params: GeneratorParams{ExcludedSubstrings: []string{"ee"}}, want: slices.Concat(star, stat)},
+		{name: "empty substring", params: GeneratorParams{ExcludedSubstrings: []string{""}}, want: slices.Concat(race, star, stat)},
+		{name: "nested entries", params: GeneratorParams{NoNestedEntries: true}, want: race},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.params.MinWordLength = 3
+			checkGrids(t, testGenerator(4, words, tc.params), "", tc.want)
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package xwgen
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Contradiction explains why a pattern has no fill.
+type Contradiction struct {
+	// Cells is a minimal set of the pattern's cells that can't be filled together: turning any one
+	// of them into AnyCell resolves the contradiction, as far as the check that found it can tell.
+	Cells []Cell
+	// Slots are the entries of the pattern that go through Cells.
+	Slots []Slot
+	// Exhaustive is true if the contradiction was confirmed by a full search, rather than by
+	// propagation alone. Finding an exhaustive contradiction can take much longer.
+	Exhaustive bool
+}
+
+func (c *Contradiction) String() string {
+	var b strings.Builder
+	b.WriteString("cells:")
+	for _, cell := range c.Cells {
+		fmt.Fprintf(&b, " %s", cell)
+	}
+	for _, slot := range c.Slots {
+		fmt.Fprintf(&b, "\n%s", slot)
+	}
+	return b.String()
+}
+
+// Explain finds which cells of pattern are responsible for it having no fill, so that they can be
+// changed. It returns nil if the pattern has a fill.
+//
+// It first checks whether propagation alone rules the pattern out, which is fast. Otherwise it
+// falls back to full searches, which can be slow for large or mostly empty patterns; ctx can be used
+// to bound the time spent.
+func (g *Generator) Explain(ctx context.Context, pattern Grid) (*Contradiction, error) {
+	if err := checkPattern(pattern, g.LineLength); err != nil {
+		return nil, err
+	}
+	cells := patternCells(pattern)
+
+	root, err := g.initialState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	unsat := root.refutedByPropagation
+	refuted, err := unsat(ctx, cells)
+	if err != nil {
+		return nil, err
+	}
+	exhaustive := false
+	if !refuted {
+		found, err := root.hasFill(ctx, cells)
+		if err != nil || found {
+			return nil, err
+		}
+		exhaustive = true
+		unsat = func(ctx context.Context, cells []Cell) (bool, error) {
+			found, err := root.hasFill(ctx, cells)
+			return !found, err
+		}
+	}
+
+	// Deletion-based search for a minimal core: drop every cell the contradiction survives without.
+	core := cells
+	for i := 0; i < len(core); {
+		without := slices.Delete(slices.Clone(core), i, i+1)
+		refuted, err := unsat(ctx, without)
+		if err != nil {
+			return nil, err
+		}
+		if refuted {
+			core = without
+		} else {
+			i++
+		}
+	}
+
+	return &Contradiction{Cells: core, Slots: slotsThrough(pattern, core), Exhaustive: exhaustive}, nil
+}
+
+// withCells returns a copy of the root of a search narrowed down to the cells, with a
+// configuration of its own. Nogoods only hold for the root they were learned from, so the copy
+// starts without any.
+func (s *gridState) withCells(cells []Cell) gridState {
+	gs := s.withLines(slices.Clone(s.down), slices.Clone(s.across))
+	gs.downReasons, gs.acrossReasons = slices.Clone(s.downReasons), slices.Clone(s.acrossReasons)
+	config := *s.config
+	config.nogoods = newNogoodStore()
+	config.failures = 0
+	gs.config = &config
+	gs.applyCells(cells)
+	return gs
+}
+
+// refutedByPropagation returns true if propagation alone shows that the cells have no fill.
+func (s *gridState) refutedByPropagation(ctx context.Context, cells []Cell) (bool, error) {
+	gs := s.withCells(cells)
+
+	if _, ok := gs.impossibleLines(); ok {
+		return true, nil
+	}
+	if _, ok := invalidWords(&gs); ok {
+		return true, nil
+	}
+	propagated, _, ok := propagate(ctx, &gs)
+	if !ok {
+		return ctx.Err() == nil, ctx.Err()
+	}
+	_, ok = invalidWords(&propagated)
+	return ok, nil
+}
+
+// hasFill returns true if a search finds a grid that agrees with the cells.
+func (s *gridState) hasFill(ctx context.Context, cells []Cell) (bool, error) {
+	gs := s.withCells(cells)
+
+	found := false
+	possibleGridsAtRoot(ctx, &gs, func(fill) bool {
+		found = true
+		return false
+	})
+	if !found && ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	return found, nil
+}
+
+// slotsThrough returns the slots of pattern that go through any of the given cells.
+func slotsThrough(pattern Grid, cells []Cell) []Slot {
+	var slots []Slot
+	for _, c := range cells {
+		for _, dir := range []Direction{DirectionHorizontal, DirectionVertical} {
+			slot, ok := slotAt(pattern, c.X, c.Y, dir)
+			if ok && !slices.Contains(slots, slot) {
+				slots = append(slots, slot)
+			}
+		}
+	}
+	return slots
+}
@@ -0,0 +1,147 @@
+package xwgen
+
+import (
+	"context"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestParseGrid(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "pattern", input: "ab.\n#?C\n...\n", want: "ab.\n`?c\n..."},
+		{name: "blank lines", input: "\nab\n\ncd\n", want: "ab\ncd"},
+		{name: "ragged", input: "abc\nab", wantErr: true},
+		{name: "bad character", input: "a1\nbc", wantErr: true},
+		{name: "empty", input: "\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grid, err := ParseGrid(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %q", grid.Repr())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseGrid returned error: %v", err)
+			}
+			if grid.Repr() != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, grid.Repr())
+			}
+		})
+	}
+}
+
+func TestPossibleGridsFrom(t *testing.T) {
+	words := loadWords(t)
+	gen := CreateGenerator(4, PreferredAndObscureTiers(words, nil), nil, rand.New(rand.NewPCG(1, 2)), GeneratorParams{MinWordLength: 3})
+	pattern, err := ParseGrid("s...\n....\n....\n?..?")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grids, err := gen.PossibleGridsFrom(context.Background(), pattern)
+	if err != nil {
+		t.Fatalf("PossibleGridsFrom returned error: %v", err)
+	}
+	count := 0
+	for grid := range grids {
+		count++
+		for y := range pattern.Height() {
+			for x := range pattern.Width() {
+				switch p, g := pattern.Get(x, y), grid.Get(x, y); p {
+				case AnyCell:
+				case OpenCell:
+					if g == BlockCell {
+						t.Errorf("expected a letter at (%d, %d):\n%s", x, y, grid.Repr())
+					}
+				default:
+					if g != p {
+						t.Errorf("expected %q at (%d, %d):\n%s", p, x, y, grid.Repr())
+					}
+				}
+			}
+		}
+		if count >= 10 {
+			break
+		}
+	}
+	if count == 0 {
+		t.Error("expected at least one grid")
+	}
+
+	if _, err := gen.PossibleGridsFrom(context.Background(), NewGrid([][]rune{[]rune("ab"), []rune("cd")})); err == nil {
+		t.Error("expected an error for a pattern of the wrong size")
+	}
+}
+
+func TestExplain(t *testing.T) {
+	words := loadWords(t)
+	gen := CreateGenerator(4, PreferredAndObscureTiers(words, nil), nil, rand.New(rand.NewPCG(1, 2)), GeneratorParams{MinWordLength: 3})
+
+	t.Run("fillable", func(t *testing.T) {
+		pattern, _ := ParseGrid("s...\n....\n....\n...?")
+		contradiction, err := gen.Explain(context.Background(), pattern)
+		if err != nil {
+			t.Fatalf("Explain returned error: %v", err)
+		}
+		if contradiction != nil {
+			t.Errorf("expected no contradiction, got %s", contradiction)
+		}
+	})
+
+	t.Run("unfillable", func(t *testing.T) {
+		// No word starts with "qx", and the seed in the bottom right is irrelevant.
+		pattern, _ := ParseGrid("qx..\n....\n....\n...e")
+		contradiction, err := gen.Explain(context.Background(), pattern)
+		if err != nil {
+			t.Fatalf("Explain returned error: %v", err)
+		}
+		if contradiction == nil {
+			t.Fatal("expected a contradiction")
+		}
+		t.Logf("contradiction: %s", contradiction)
+
+		for _, c := range contradiction.Cells {
+			if c.Y != 0 || c.X > 1 {
+				t.Errorf("expected only cells in the top left to be blamed, got %s", c)
+			}
+		}
+		blamed := false
+		for _, slot := range contradiction.Slots {
+			if slot.Direction == DirectionHorizontal && slot.X == 0 && slot.Y == 0 && slot.Pattern == "qx.." {
+				blamed = true
+			}
+		}
+		if !blamed {
+			t.Errorf("expected the first across slot to be blamed, got %s", contradiction)
+		}
+	})
+}
+
+func TestExplain_KeepsStats(t *testing.T) {
+	gen := CreateGenerator(4, PreferredAndObscureTiers(loadWords(t), nil), nil, rand.New(rand.NewPCG(1, 2)), GeneratorParams{MinWordLength: 3})
+	pattern, _ := ParseGrid("s...\n....\n....\n...?")
+	grids, err := gen.PossibleGridsFrom(context.Background(), pattern)
+	if err != nil {
+		t.Fatalf("PossibleGridsFrom returned error: %v", err)
+	}
+	for range grids {
+		break
+	}
+	stats := gen.SearchStats()
+
+	unfillable, _ := ParseGrid("qx..\n....\n....\n...e")
+	if _, err := gen.Explain(context.Background(), unfillable); err != nil {
+		t.Fatalf("Explain returned error: %v", err)
+	}
+	if got := gen.SearchStats(); got != stats {
+		t.Errorf("expected Explain to keep the search stats %+v, got %+v", stats, got)
+	}
+}
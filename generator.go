@@ -4,7 +4,9 @@ import (
 	"context"
 	"iter"
 	"math/rand/v2"
+	"regexp"
 	"slices"
+	"time"
 
 	"github.com/Eyas/xwgen/internal"
 	"github.com/Eyas/xwgen/pkg/primitives"
@@ -19,25 +21,127 @@ const (
 )
 
 type Generator struct {
-	LineLength     int
-	PreferredWords []string
-	ObscureWords   []string
-	ExcludedWords  []string
-	MinWordLength  *int
-	MaxWordLength  *int
+	LineLength    int
+	Tiers         []WordTier
+	ExcludedWords []string
+	MinWordLength *int
+	MaxWordLength *int
+
+	// ExcludedPatterns excludes every word matched by any of the patterns (see GlobPattern), and
+	// ExcludedSubstrings excludes every word containing any of the substrings. Empty substrings are
+	// ignored.
+	ExcludedPatterns   []*regexp.Regexp
+	ExcludedSubstrings []string
+	// NoNestedEntries rejects grids where one entry contains another, e.g. "parts" and "art".
+	NoNestedEntries bool
+	// Related decides which words count as duplicates of each other, e.g. "see" and "sees". If nil,
+	// only identical words are duplicates.
+	Related WordRelation
+	// Structure holds the rules on the layout of blocks.
+	Structure StructureRules
+	// Checking allows unchecked cells, for cryptic-style grids.
+	Checking CheckingRules
+	// Barred generates barred grids, where entries are separated by bars between cells rather than
+	// by blocks. See Grid.Barred.
+	Barred bool
+	// DedupSymmetries yields a single grid out of every set of grids that are rotations or
+	// reflections of one another (see Grid.Canonical), and skips branches whose grids would all be
+	// transpositions of grids in other branches.
+	DedupSymmetries bool
+	// Dedup bounds the memory used to remember the grids already yielded.
+	Dedup DedupOptions
+	// Diversity makes PossibleGrids skip grids that are too close to those it already yielded.
+	Diversity DiversityOptions
+	// SampleCandidates is the number of random walks Sample makes for each grid it yields. Zero
+	// means 8.
+	SampleCandidates int
+
+	// MaxLowScoreEntries caps how many low-score entries a single grid may contain. A word is
+	// low-score if its tier's weight is below LowScoreThreshold or, if LowScoreThreshold is zero, if
+	// it is in any tier but the first. Zero means no cap.
+	MaxLowScoreEntries int
+	LowScoreThreshold  float64
+
+	// FixedPassPropagation uses a fixed number of alternating filtering passes, rather than
+	// propagating to a fixpoint. It prunes less, and is kept for comparison.
+	FixedPassPropagation bool
+	// DisableConflictLearning turns off conflict-directed backjumping and nogood learning, so the
+	// search always backtracks chronologically.
+	DisableConflictLearning bool
+
+	// RestartBase enables randomized restarts: the search is abandoned after RestartBase failures and
+	// started over with a fresh random ordering, then after 2*RestartBase failures, and so on
+	// following the Luby sequence. Grids already yielded are not yielded again. Zero means the search
+	// never restarts.
+	RestartBase int
+
+	// Observer, if set, is called with the progress of PossibleGrids about every ObserveInterval,
+	// and once more when the search ends.
+	Observer        func(SearchStats)
+	ObserveInterval time.Duration
+
+	// Resume, if set, continues the search of a Checkpoint rather than starting a new one. Only the
+	// next search resumes it, and it is cleared once that search starts.
+	Resume *Checkpoint
+	// OnCheckpoint, if set, is called with a checkpoint of PossibleGrids about every
+	// CheckpointInterval. See Generator.Checkpoint.
+	OnCheckpoint       func(*Checkpoint)
+	CheckpointInterval time.Duration
 
 	rand *rand.Rand
+	// linesSeed seeds the order of the possible lines, once hasLinesSeed is set.
+	linesSeed    [2]uint64
+	hasLinesSeed bool
+
+	// searchSource is the random source of the most recent search, seen holds the grids it yielded
+	// and trail follows it for checkpoints.
+	searchSource *rand.PCG
+	seen         seenSet
+	trail        *searchTrail
+
+	searchStats SearchStats
+	searchErr   error
 
 	// Do not access this field directly, use the allPossibleLines method instead.
 	lazyAllPossibleLines primitives.PossibleLines
+	// Do not access this field directly, use the wordTiers method instead.
+	lazyWordTiers map[string]int
+	// Do not access this field directly, use the queryWords method instead.
+	lazyQueryWords map[int]primitives.PossibleLines
 }
 
 type GeneratorParams struct {
-	MinWordLength int
-	MaxWordLength int
+	MinWordLength      int
+	MaxWordLength      int
+	MaxLowScoreEntries int
+	LowScoreThreshold  float64
+	ExcludedPatterns   []*regexp.Regexp
+	ExcludedSubstrings []string
+	NoNestedEntries    bool
+	Related            WordRelation
+	Structure          StructureRules
+	Checking           CheckingRules
+	Barred             bool
+	DedupSymmetries    bool
+	Dedup              DedupOptions
+	Diversity          DiversityOptions
+	SampleCandidates   int
+
+	FixedPassPropagation    bool
+	DisableConflictLearning bool
+	RestartBase             int
+
+	Observer        func(SearchStats)
+	ObserveInterval time.Duration
+
+	Resume             *Checkpoint
+	OnCheckpoint       func(*Checkpoint)
+	CheckpointInterval time.Duration
 }
 
-func CreateGenerator(lineLength int, preferredWords, obscureWords, excludedWords []string, rand *rand.Rand, params GeneratorParams) *Generator {
+// CreateGenerator creates a generator for square grids of the given size, filled with words from
+// the given tiers. See PreferredAndObscureTiers for the classic two-tier setup.
+func CreateGenerator(lineLength int, tiers []WordTier, excludedWords []string, rand *rand.Rand, params GeneratorParams) *Generator {
 	var minWordLength, maxWordLength *int
 	if params.MinWordLength > 0 {
 		minWordLength = &params.MinWordLength
@@ -46,35 +150,284 @@ func CreateGenerator(lineLength int, preferredWords, obscureWords, excludedWords
 		maxWordLength = &params.MaxWordLength
 	}
 	return &Generator{
-		LineLength:     lineLength,
-		PreferredWords: preferredWords,
-		ObscureWords:   obscureWords,
-		ExcludedWords:  excludedWords,
-		MinWordLength:  minWordLength,
-		MaxWordLength:  maxWordLength,
-		rand:           rand,
+		LineLength:    lineLength,
+		Tiers:         tiers,
+		ExcludedWords: excludedWords,
+		MinWordLength: minWordLength,
+		MaxWordLength: maxWordLength,
+
+		MaxLowScoreEntries: params.MaxLowScoreEntries,
+		LowScoreThreshold:  params.LowScoreThreshold,
+		ExcludedPatterns:   params.ExcludedPatterns,
+		ExcludedSubstrings: params.ExcludedSubstrings,
+		NoNestedEntries:    params.NoNestedEntries,
+		Related:            params.Related,
+		Structure:          params.Structure,
+		Checking:           params.Checking,
+		Barred:             params.Barred,
+		DedupSymmetries:    params.DedupSymmetries,
+		Dedup:              params.Dedup,
+		Diversity:          params.Diversity,
+		SampleCandidates:   params.SampleCandidates,
+
+		FixedPassPropagation:    params.FixedPassPropagation,
+		DisableConflictLearning: params.DisableConflictLearning,
+		RestartBase:             params.RestartBase,
+
+		Observer:        params.Observer,
+		ObserveInterval: params.ObserveInterval,
+
+		Resume:             params.Resume,
+		OnCheckpoint:       params.OnCheckpoint,
+		CheckpointInterval: params.CheckpointInterval,
+
+		rand: rand,
 	}
 }
 
 func (g *Generator) allPossibleLines(ctx context.Context) (primitives.PossibleLines, error) {
 	var err error
 	if g.lazyAllPossibleLines == nil {
+		tiers := make([][]string, len(g.Tiers))
+		for i, tier := range g.Tiers {
+			tiers[i] = tier.Words
+		}
+		if !g.hasLinesSeed {
+			g.linesSeed, g.hasLinesSeed = [2]uint64{g.randUint64(), g.randUint64()}, true
+		}
 		g.lazyAllPossibleLines, err = internal.AllPossibleLines(ctx, internal.AllPossibleLinesParams{
-			LineLength:     g.LineLength,
-			PreferredWords: g.PreferredWords,
-			ObscureWords:   g.ObscureWords,
-			ExcludedWords:  g.ExcludedWords,
+			LineLength:         g.LineLength,
+			Tiers:              tiers,
+			ExcludedWords:      g.ExcludedWords,
+			ExcludedPatterns:   g.ExcludedPatterns,
+			ExcludedSubstrings: g.ExcludedSubstrings,
+			MinWordLength:      g.MinWordLength,
+			MaxWordLength:      g.MaxWordLength,
+			AllowUnchecked:     g.Checking.AllowUnchecked,
+			Barred:             g.Barred,
+			Rand:               rand.New(rand.NewPCG(g.linesSeed[0], g.linesSeed[1])),
 		})
 	}
 	return g.lazyAllPossibleLines, err
 }
 
+// searchConfig holds the generator-wide rules consulted while searching.
+type searchConfig struct {
+	// wordTiers maps each word to its tier index, and tierCaps[t] is the maximum number of entries
+	// from tier t a grid may use (0 means no cap).
+	wordTiers map[string]int
+	tierCaps  []int
+
+	// lowScoreTiers[t] is true if words in tier t count towards maxLowScore (0 means no cap).
+	lowScoreTiers []bool
+	maxLowScore   int
+
+	noNestedEntries bool
+	structure       StructureRules
+	barred          bool
+	checking        CheckingRules
+	minWordLength   int
+	// trail follows the search for checkpoints, and steers it when resuming. It is nil unless the
+	// search can be checkpointed.
+	trail *searchTrail
+	// pruneTransposed skips branches whose grids all come after their transpositions, which are
+	// found in other branches.
+	pruneTransposed bool
+
+	// related is the rule for duplicate words, and relatedWords maps each root to every word in the
+	// word list with that root. Both are nil if only identical words are duplicates.
+	related      WordRelation
+	relatedWords map[string][]string
+
+	// fixedPassPropagation selects the original fixed-pass propagation over AC-3.
+	fixedPassPropagation bool
+	propagationStats     *PropagationStats
+
+	// conflictLearning enables backjumping and nogood learning.
+	conflictLearning bool
+	nogoods          *nogoodStore
+	conflictStats    *ConflictStats
+
+	// randomizeOrder shuffles the order in which the possibilities of a line are tried, so that
+	// restarts explore different parts of the search space.
+	randomizeOrder bool
+	// failures counts the failed subtrees since the last restart, and restartAfter is the number of
+	// failures after which the search restarts (0 means never).
+	failures     int64
+	restartAfter int64
+
+	progress *searchProgress
+}
+
+func (g *Generator) searchConfig() *searchConfig {
+	caps := make([]int, len(g.Tiers))
+	lowScore := make([]bool, len(g.Tiers))
+	for i, tier := range g.Tiers {
+		caps[i] = tier.MaxEntries
+		if g.LowScoreThreshold != 0 {
+			lowScore[i] = tier.Weight < g.LowScoreThreshold
+		} else {
+			lowScore[i] = i > 0
+		}
+	}
+	minWordLength := 3
+	if g.MinWordLength != nil {
+		minWordLength = *g.MinWordLength
+	}
+	structure := g.Structure
+	if structure.MinRegionSize == 0 {
+		structure.MinRegionSize = minWordLength
+	}
+	progress := newSearchProgress(g.Observer, g.ObserveInterval)
+	return &searchConfig{
+		wordTiers:     g.wordTiers(),
+		tierCaps:      caps,
+		lowScoreTiers: lowScore,
+		maxLowScore:   g.MaxLowScoreEntries,
+
+		noNestedEntries: g.NoNestedEntries,
+		structure:       structure,
+		barred:          g.Barred,
+		checking:        g.Checking,
+		minWordLength:   minWordLength,
+
+		related:      g.Related,
+		relatedWords: g.relatedWords(),
+
+		fixedPassPropagation: g.FixedPassPropagation,
+		propagationStats:     &progress.stats.Propagation,
+
+		conflictLearning: !g.DisableConflictLearning,
+		nogoods:          newNogoodStore(),
+		conflictStats:    &progress.stats.Conflicts,
+
+		randomizeOrder: g.restarts(),
+
+		progress: progress,
+	}
+}
+
+func (g *Generator) relatedWords() map[string][]string {
+	if g.Related == nil {
+		return nil
+	}
+	words := make(map[string][]string)
+	for _, tier := range g.Tiers {
+		for _, word := range tier.Words {
+			root := g.Related.Root(word)
+			if !slices.Contains(words[root], word) {
+				words[root] = append(words[root], word)
+			}
+		}
+	}
+	return words
+}
+
+// root returns the key under which word is checked for duplicates.
+func (c *searchConfig) root(word string) string {
+	if c.related == nil {
+		return word
+	}
+	return c.related.Root(word)
+}
+
+// withRelated returns the given words along with every word in the word list related to them.
+func (c *searchConfig) withRelated(words []string) []string {
+	if c.related == nil {
+		return words
+	}
+	all := slices.Clone(words)
+	for _, word := range words {
+		for _, related := range c.relatedWords[c.root(word)] {
+			if !slices.Contains(all, related) {
+				all = append(all, related)
+			}
+		}
+	}
+	return all
+}
+
+// exceedsWordCaps returns true if the given words use more entries from some tier, or more
+// low-score entries, than allowed.
+func (c *searchConfig) exceedsWordCaps(words map[string]bool) bool {
+	if c.maxLowScore <= 0 && !slices.ContainsFunc(c.tierCaps, func(limit int) bool { return limit > 0 }) {
+		return false
+	}
+	counts := make([]int, len(c.tierCaps))
+	numLowScore := 0
+	for word := range words {
+		t, ok := c.wordTiers[word]
+		if !ok {
+			continue
+		}
+		counts[t]++
+		if c.tierCaps[t] > 0 && counts[t] > c.tierCaps[t] {
+			return true
+		}
+		if c.lowScoreTiers[t] {
+			numLowScore++
+			if c.maxLowScore > 0 && numLowScore > c.maxLowScore {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // gridState represents the state of a grid being generated so far.
 type gridState struct {
 	down   []primitives.PossibleLines
 	across []primitives.PossibleLines
 
-	rand *rand.Rand
+	// downReasons[i] and acrossReasons[i] hold the decision levels that narrowed down[i] and
+	// across[i] from their initial possibilities.
+	downReasons   []levelSet
+	acrossReasons []levelSet
+	// path holds the decision made at each level so far.
+	path []decision
+
+	rand   *rand.Rand
+	config *searchConfig
+}
+
+// withLines returns a copy of the state with the given lines, sharing everything else.
+func (s gridState) withLines(down, across []primitives.PossibleLines) gridState {
+	s.down = down
+	s.across = across
+	return s
+}
+
+// depth returns the number of decisions made to reach this state, which is also the level of any
+// decision made from it.
+func (s *gridState) depth() int {
+	return len(s.path)
+}
+
+func (s *gridState) reasons(dir Direction) []levelSet {
+	if dir == DirectionHorizontal {
+		return s.acrossReasons
+	}
+	return s.downReasons
+}
+
+// impossibleLines returns the reasons for every line with no possibilities, and false if there are
+// none.
+func (s *gridState) impossibleLines() (levelSet, bool) {
+	var conflict levelSet
+	found := false
+	for i, line := range s.across {
+		if impossible(line) {
+			conflict = conflict.union(s.acrossReasons[i])
+			found = true
+		}
+	}
+	for i, line := range s.down {
+		if impossible(line) {
+			conflict = conflict.union(s.downReasons[i])
+			found = true
+		}
+	}
+	return conflict, found
 }
 
 // getUndecidedIndexWLOG returns an index of an undecided line (i.e. a line that is not yet decided),
@@ -87,10 +440,10 @@ func getUndecidedIndexWLOG(lines []primitives.PossibleLines, rand *rand.Rand) *i
 	var least int64
 	var opts []option
 	for i, line := range lines {
-		p := line.MaxPossibilities()
-		if p <= 1 {
+		if line.Decided() {
 			continue
 		}
+		p := line.MaxPossibilities()
 		if least == 0 || p < least {
 			least = p
 		}
@@ -125,109 +478,175 @@ func (s gridState) getUndecidedIndexAcross() *int {
 	return getUndecidedIndexWLOG(s.across, s.rand)
 }
 
-func prefilter(ctx context.Context, s gridState, dir Direction) (gridState, bool) {
-	if slices.ContainsFunc(s.down, impossible) || slices.ContainsFunc(s.across, impossible) {
-		return s, false
-	}
-	if ctx.Err() != nil {
-		return s, false
+// initialState returns the root of a search, where every line may be any possible line.
+func (g *Generator) initialState(ctx context.Context) (gridState, error) {
+	gs := gridState{
+		down:          make([]primitives.PossibleLines, g.LineLength),
+		across:        make([]primitives.PossibleLines, g.LineLength),
+		downReasons:   make([]levelSet, g.LineLength),
+		acrossReasons: make([]levelSet, g.LineLength),
+		rand:          g.rand,
+		config:        g.searchConfig(),
 	}
 
-	var toFilter, constraint []primitives.PossibleLines
-	if dir == DirectionHorizontal {
-		toFilter = s.across
-		constraint = s.down
-	} else {
-		toFilter = s.down
-		constraint = s.across
+	apl, err := g.allPossibleLines(ctx)
+	if err != nil {
+		return gs, err
 	}
 
-	// i and j here are abstracted wlog based on toFilter/constraint, not truly
-	// connected to Horizontal vs Vertical.
-	//
-	// available[i][j] is the set of characters that can be placed at (x, y) in the grid.
-	available := make([][]primitives.CharSet, len(constraint))
-	for i, constraintLine := range constraint {
-		available[i] = make([]primitives.CharSet, constraintLine.NumLetters())
-
-		for j := range constraintLine.NumLetters() {
-			available[i][j] = *primitives.DefaultCharSet()
-			constraintLine.CharsAt(&available[i][j], j)
-		}
+	for i := range gs.down {
+		gs.down[i] = apl
 	}
-
-	anyChanged := false
-	for j := range toFilter {
-		tf := toFilter[j]
-
-		// if all characters in available[i] are full, then the line cannot be filtered
-		// any further.
-		allFull := true
-		for i := range tf.NumLetters() {
-			if !available[i][j].IsFull() {
-				allFull = false
-				break
-			}
-		}
-		if allFull {
-			continue
-		}
-
-		newTf := tf
-		for i := range tf.NumLetters() {
-			newTf = newTf.FilterAny(&available[i][j], i)
-		}
-		if newTf != tf {
-			anyChanged = true
-			toFilter[j] = newTf
-		}
+	for i := range gs.across {
+		gs.across[i] = apl
 	}
+	return gs, nil
+}
 
-	if dir == DirectionHorizontal {
-		return gridState{across: toFilter, down: constraint, rand: s.rand}, anyChanged
-	} else {
-		return gridState{down: toFilter, across: constraint, rand: s.rand}, anyChanged
+func (g *Generator) PossibleGrids(ctx context.Context) iter.Seq[Grid] {
+	return g.possibleGrids(ctx, "", func(*gridState) {}, true)
+}
+
+// PossibleGridsFrom returns the grids that fill the given pattern (see ParseGrid), keeping its
+// letters and blocks. It returns an error if the pattern doesn't match the generator's grid size.
+//
+// If the pattern has no fill, Explain can tell which of its cells are to blame.
+func (g *Generator) PossibleGridsFrom(ctx context.Context, pattern Grid) (iter.Seq[Grid], error) {
+	if err := checkPattern(pattern, g.LineLength); err != nil {
+		return nil, err
 	}
+	cells := patternCells(pattern)
+	transposable := pattern.Repr() == pattern.transform(symmetry{transpose: true}).Repr()
+	return g.possibleGrids(ctx, pattern.Repr(), func(s *gridState) { s.applyCells(cells) }, transposable), nil
 }
 
-func (g *Generator) PossibleGrids(ctx context.Context) iter.Seq[Grid] {
+// possibleGrids returns the grids reachable from the initial state, once narrowed down by setup,
+// which pattern identifies for checkpoints. If transposable, setup narrows the rows and the columns
+// alike, so the transposition of every grid is also a grid.
+func (g *Generator) possibleGrids(ctx context.Context, pattern string, setup func(*gridState), transposable bool) iter.Seq[Grid] {
 	return func(yield func(Grid) bool) {
-		gs := gridState{
-			down:   make([]primitives.PossibleLines, g.LineLength),
-			across: make([]primitives.PossibleLines, g.LineLength),
-			rand:   g.rand,
+		g.searchErr = nil
+		// The random source and the order of the lines must be set before the lines are built.
+		if err := g.startTrail(pattern); err != nil {
+			g.searchErr = err
+			return
 		}
-
-		apl, err := g.allPossibleLines(ctx)
+		seen := g.seen
+		gs, err := g.initialState(ctx)
 		if err != nil {
+			g.searchErr = err
 			return
 		}
-
-		for i := range gs.down {
-			gs.down[i] = apl
+		setup(&gs)
+		gs.rand = rand.New(g.searchSource)
+		gs.config.pruneTransposed = g.DedupSymmetries && transposable
+		if !g.restarts() {
+			gs.config.trail = g.trail
 		}
-		for i := range gs.across {
-			gs.across[i] = apl
+		if g.trail.done {
+			return
 		}
 
-		seenReprs := make(map[string]bool)
-		for grid := range possibleGridsAtRoot(ctx, &gs) {
+		progress := gs.config.progress
+		defer func() {
+			g.searchStats = progress.finish()
+		}()
+
+		var diverse *diverseSet
+		if g.Diversity.enabled() {
+			diverse = &diverseSet{opts: g.Diversity}
+		}
+		// restartNow is set to stop the search from within dedup, to restart it from the root.
+		restartNow := false
+
+		// Grids already yielded are remembered across restarts.
+		dedup := func(f fill) bool {
+			gs.config.progress.stats.Grids++
+			var d diverseGrid
+			if diverse != nil {
+				if d = newDiverseGrid(f); !diverse.differs(d) {
+					config := gs.config
+					config.failures++
+					restartNow = config.restartAfter > 0 && config.failures >= config.restartAfter
+					return !restartNow
+				}
+			}
+			grid := f.grid()
 			repr := grid.Repr()
-			if seenReprs[repr] {
-				continue
+			if g.DedupSymmetries {
+				repr = grid.Canonical().Repr()
+			}
+			added, err := seen.add(gridHash(repr))
+			if err != nil {
+				g.searchErr = err
+				return false
+			}
+			if !added {
+				return true
 			}
-			seenReprs[repr] = true
 			if !yield(grid) {
+				return false
+			}
+			if diverse == nil {
+				return true
+			}
+			// The next grid is looked for from the root, with a fresh random ordering, rather than in
+			// the branches next to this one.
+			diverse.grids = append(diverse.grids, d)
+			restartNow = true
+			return false
+		}
+
+		for run := 1; ; run++ {
+			if g.RestartBase > 0 {
+				gs.config.failures = 0
+				gs.config.restartAfter = int64(g.RestartBase) * luby(run)
+			}
+			res := possibleGridsAtRoot(ctx, &gs, dedup)
+			if restartNow {
+				res.restart, restartNow = true, false
+			}
+			if !res.stop {
+				g.trail.done = true
+			}
+			if !res.restart {
 				return
 			}
+			progress.stats.Conflicts.Restarts++
 		}
 	}
 }
 
+// restarts returns true if searches restart from the root, which shuffles the order of the
+// branches.
+func (g *Generator) restarts() bool {
+	return g.RestartBase > 0 || g.Diversity.enabled()
+}
+
+// Err returns the error that stopped the most recent call to PossibleGrids early, if any. The end of
+// the context is not an error.
+func (g *Generator) Err() error {
+	return g.searchErr
+}
+
+// SearchStats returns the progress of the most recent call to PossibleGrids.
+func (g *Generator) SearchStats() SearchStats {
+	return g.searchStats
+}
+
 func impossible(p primitives.PossibleLines) bool {
 	return p.MaxPossibilities() == 0
 }
 
+// splitLine splits line in two, like MakeChoice. A decided line is kept whole, as the letters of its
+// unchecked runs are left to the crossing entries.
+func splitLine(line primitives.PossibleLines) primitives.ChoiceStep {
+	if line.Decided() {
+		return primitives.ChoiceStep{Choice: line, Remaining: primitives.MakeImpossible(line.NumLetters())}
+	}
+	return line.MakeChoice()
+}
+
 func countWhere[T any](s []T, f func(T) bool) int {
 	count := 0
 	for _, v := range s {
@@ -238,138 +657,214 @@ func countWhere[T any](s []T, f func(T) bool) int {
 	return count
 }
 
-func possibleGridsAtRoot(ctx context.Context, root *gridState) iter.Seq[Grid] {
-	return func(yield func(Grid) bool) {
-		if ctx.Err() != nil {
-			return
-		}
+// searchResult describes how a subtree of the search ended.
+type searchResult struct {
+	// conflict holds the decision levels that explain why the subtree yielded no (further) grids.
+	// Sibling subtrees that differ only in a decision outside of conflict would fail the same way.
+	conflict levelSet
+	// found is true if the subtree yielded at least one grid.
+	found bool
+	// stop is true if the whole search should stop, e.g. because the context is done or the consumer
+	// stopped iterating.
+	stop bool
+	// restart is true if the search was stopped to be restarted from the root.
+	restart bool
+}
 
-		// If we are at a point in our tree some row/column is unfillable, prune this tree.
-		if slices.ContainsFunc(root.down, impossible) || slices.ContainsFunc(root.across, impossible) {
-			return
-		}
+// fill is a complete grid found by the search, as its rows and columns. The Grid is only built by
+// callers that need it.
+type fill struct {
+	across, down []*primitives.ConcreteLine
+	barred       bool
+}
 
-		// If there are any repeated words already, this is not a valid grid.
-		existingWords := make(map[string]bool)
-		hasDupes := false
-		for _, line := range root.down {
-			for _, word := range line.DefiniteWords() {
-				if existingWords[word] {
-					hasDupes = true
-				}
-				existingWords[word] = true
-			}
-		}
-		for _, line := range root.across {
-			for _, word := range line.DefiniteWords() {
-				if existingWords[word] {
-					hasDupes = true
-				}
-				existingWords[word] = true
-			}
-		}
-		if hasDupes {
-			return
-		}
+// grid returns the fill as a Grid.
+func (f fill) grid() Grid {
+	if f.barred {
+		return barredGrid(f.across, f.down)
+	}
+	rows := make([][]rune, len(f.across))
+	for i, a := range f.across {
+		rows[i] = a.Line
+	}
+	return NewGrid(rows)
+}
 
-		priorNumBlocked := 0
-		lineLength := len(root.down)
-		for i := range lineLength {
-			priorNumBlocked += countWhere(root.down, func(p primitives.PossibleLines) bool {
-				return p.DefinitelyBlockedAt(i)
-			})
-		}
+func possibleGridsAtRoot(ctx context.Context, root *gridState, yield func(fill) bool) searchResult {
+	if ctx.Err() != nil {
+		return searchResult{stop: true}
+	}
+	progress := root.config.progress
+	progress.visit(root.depth())
+	root.config.trail.visit(root.depth())
+
+	// Checks that consider the grid as a whole can't tell which decisions are to blame, so they
+	// blame all of them.
+	everything := searchResult{conflict: levelsUpTo(root.depth())}
+
+	// If we are at a point in our tree some row/column is unfillable, prune this tree.
+	if conflict, ok := root.impossibleLines(); ok {
+		progress.prune(PruneImpossibleLine)
+		return searchResult{conflict: conflict}
+	}
 
-		// Prefilter
-		direction := DirectionHorizontal
-		for try := range 4 {
-			newState, changed := prefilter(ctx, *root, direction)
-			if !changed && try > 1 {
-				break
-			}
+	// If there are any repeated words already, this is not a valid grid.
+	if reason, ok := invalidWords(root); ok {
+		progress.prune(reason)
+		return everything
+	}
 
-			root = &newState
-			if direction == DirectionVertical {
-				direction = DirectionHorizontal
-			} else {
-				direction = DirectionVertical
-			}
+	priorNumBlocked := 0
+	lineLength := len(root.down)
+	for i := range lineLength {
+		priorNumBlocked += countWhere(root.down, func(p primitives.PossibleLines) bool {
+			return p.DefinitelyBlockedAt(i)
+		})
+	}
+
+	// Narrow down every line to those that agree with their crossing lines.
+	propagated, conflict, ok := propagate(ctx, root)
+	if !ok {
+		if ctx.Err() != nil {
+			return searchResult{stop: true}
 		}
-		if slices.ContainsFunc(root.down, impossible) || slices.ContainsFunc(root.across, impossible) {
-			return
+		progress.prune(PruneImpossibleLine)
+		return searchResult{conflict: conflict}
+	}
+	root = &propagated
+	if reason, ok := invalidWords(root); ok {
+		progress.prune(reason)
+		return everything
+	}
+	if root.config.conflictLearning {
+		if conflict, ok := root.config.nogoods.match(root); ok {
+			root.config.conflictStats.NogoodHits++
+			progress.prune(PruneNogood)
+			return searchResult{conflict: conflict}
 		}
+	}
 
-		// If board is > 25% blocked, it's not worth iterating in it.
-		numDefinitelyBlocked := 0
-		for i := range lineLength {
-			numDefinitelyBlocked += countWhere(root.down, func(p primitives.PossibleLines) bool {
-				return p.DefinitelyBlockedAt(i)
-			})
-		}
+	if root.config.pruneTransposed && transposeIsSmaller(root) {
+		progress.prune(PruneSymmetry)
+		return everything
+	}
 
-		if numDefinitelyBlocked > ((lineLength * lineLength * 25) / 100) {
-			return
+	// Runs of letters become known as their letters do, not only as blocks are placed, so the
+	// checking rules are worth checking at every node.
+	if breaksChecking(root, root.config.checking, root.config.minWordLength) {
+		progress.prune(PruneStructure)
+		return everything
+	}
+
+	// If board is > 25% blocked, it's not worth iterating in it.
+	numDefinitelyBlocked := 0
+	for i := range lineLength {
+		numDefinitelyBlocked += countWhere(root.down, func(p primitives.PossibleLines) bool {
+			return p.DefinitelyBlockedAt(i)
+		})
+	}
+
+	if numDefinitelyBlocked > ((lineLength * lineLength * 25) / 100) {
+		progress.prune(PruneBlockDensity)
+		return everything
+	}
+
+	// If board is entirely divided, s.t. no word spans two "halves" of the
+	// board, we want to stop.
+	//
+	// We already can't have entire blocked lines. But we can have:
+	// _ _ _ ` ` `
+	// ` ` ` _ _ _
+	//
+	// This doesn't account for a "quadrant" joined to the rest through a
+	// single cell; StructureRules.MinConnectivity checks for that.
+	if numDefinitelyBlocked > priorNumBlocked {
+		if isBoardDefinitelyDivided(root) {
+			progress.prune(PruneDividedBoard)
+			return everything
 		}
+		if breaksStructure(root, root.config.structure) {
+			progress.prune(PruneStructure)
+			return everything
+		}
+	}
 
-		// If board is entirely divided, s.t. no word spans two "halves" of the
-		// board, we want to stop.
-		//
-		// We already can't have entire blocked lines. But we can have:
-		// _ _ _ ` ` `
-		// ` ` ` _ _ _
-		//
-		// This can still be better, e.g. it doesn't account for a "quadrant"
-		// being cordoned off.
-		if numDefinitelyBlocked > priorNumBlocked {
-			if isBoardDefinitelyDivided(root) {
-				return
-			}
+	undecidedDown := root.getUndecidedIndexDown()
+	undecidedAcross := root.getUndecidedIndexAcross()
+	if undecidedDown != nil || undecidedAcross != nil {
+		// Resumed searches decide the same lines as before on the way back to their checkpoint.
+		if step, ok := root.config.trail.resumeStep(root.depth()); ok {
+			return iterateAllPossibleGrids(ctx, root, step.Index, step.Dir, yield)
 		}
+	}
 
-		undecidedDown := root.getUndecidedIndexDown()
-		undecidedAcross := root.getUndecidedIndexAcross()
+	if undecidedDown == nil && undecidedAcross == nil {
+		// Blocks placed by the decision that led here may not have been checked yet.
+		if breaksStructure(root, root.config.structure) {
+			progress.prune(PruneStructure)
+			return everything
+		}
 
-		if undecidedDown == nil && undecidedAcross == nil {
-			across := make([][]rune, len(root.across))
+		acrossLines := make([]*primitives.ConcreteLine, len(root.across))
+		downLines := make([]*primitives.ConcreteLine, len(root.down))
 
-			for i, ac := range root.across {
-				a := ac.FirstOrNull()
-				d := root.down[i].FirstOrNull()
+		for i, ac := range root.across {
+			a := ac.FirstOrNull()
+			d := root.down[i].FirstOrNull()
 
-				if d == nil || a == nil {
-					return
-				}
-
-				// If any column and row are completely the same, this is not a viable grid.
-				if slices.Equal(d.Line, a.Line) {
-					return
-				}
+			if d == nil || a == nil {
+				progress.prune(PruneImpossibleLine)
+				return everything
+			}
 
-				across[i] = a.Line
+			// If any column and row are completely the same, this is not a viable grid.
+			if sameLine(d, a) {
+				progress.prune(PruneDuplicateWord)
+				return everything
 			}
 
-			yield(NewGrid(across))
-			return
+			acrossLines[i], downLines[i] = a, d
 		}
 
-		var possibleGrids iter.Seq[Grid]
-
-		if undecidedAcross == nil {
-			possibleGrids = iterateAllPossibleGrids(ctx, root, *undecidedDown, DirectionVertical)
-		} else if undecidedDown == nil {
-			possibleGrids = iterateAllPossibleGrids(ctx, root, *undecidedAcross, DirectionHorizontal)
-		} else if root.down[*undecidedDown].MaxPossibilities() <= root.across[*undecidedAcross].MaxPossibilities() {
-			possibleGrids = iterateAllPossibleGrids(ctx, root, *undecidedDown, DirectionVertical)
-		} else {
-			possibleGrids = iterateAllPossibleGrids(ctx, root, *undecidedAcross, DirectionHorizontal)
+		if !yield(fill{across: acrossLines, down: downLines, barred: root.config.barred}) {
+			return searchResult{stop: true}
 		}
+		return searchResult{conflict: everything.conflict, found: true}
+	}
 
-		for grid := range possibleGrids {
-			if !yield(grid) {
-				return
+	if undecidedAcross == nil {
+		return iterateAllPossibleGrids(ctx, root, *undecidedDown, DirectionVertical, yield)
+	} else if undecidedDown == nil {
+		return iterateAllPossibleGrids(ctx, root, *undecidedAcross, DirectionHorizontal, yield)
+	} else if root.down[*undecidedDown].MaxPossibilities() <= root.across[*undecidedAcross].MaxPossibilities() {
+		return iterateAllPossibleGrids(ctx, root, *undecidedDown, DirectionVertical, yield)
+	} else {
+		return iterateAllPossibleGrids(ctx, root, *undecidedAcross, DirectionHorizontal, yield)
+	}
+}
+
+// invalidWords returns true, and the reason, if the words definitely present in the grid break a
+// word rule, e.g. a word is repeated, too many words come from a capped tier, or one word contains
+// another.
+func invalidWords(state *gridState) (PruneReason, bool) {
+	existingWords := make(map[string]bool)
+	existingRoots := make(map[string]bool)
+	for _, lines := range [][]primitives.PossibleLines{state.down, state.across} {
+		for _, line := range lines {
+			for _, word := range line.DefiniteWords() {
+				root := state.config.root(word)
+				if existingRoots[root] {
+					return PruneDuplicateWord, true
+				}
+				existingRoots[root] = true
+				existingWords[word] = true
 			}
 		}
 	}
+	if (state.config.noNestedEntries && hasNestedEntry(existingWords)) || state.config.exceedsWordCaps(existingWords) {
+		return PruneWordRule, true
+	}
+	return 0, false
 }
 
 func isBoardDefinitelyDivided(state *gridState) bool {
@@ -445,177 +940,170 @@ func isBoardDefinitelyDivided(state *gridState) bool {
 	return false
 }
 
-func iterateAllPossibleGrids(ctx context.Context, root *gridState, index int, dir Direction) iter.Seq[Grid] {
-	return func(yield func(Grid) bool) {
-		if ctx.Err() != nil {
-			return
-		}
+// branchOutcome accumulates the results of the children of a single search node, deciding when to
+// backjump and which nogoods to learn.
+type branchOutcome struct {
+	root  *gridState
+	level int
+	// acc accumulates the conflicts of the children tried so far, excluding this node's level.
+	acc   levelSet
+	found bool
+}
 
-		var optionAxis, oppositeAxis []primitives.PossibleLines
+// child records the result of the child reached by making decision d. It returns a result to
+// return from the node immediately, or nil if the next child should be tried.
+func (b *branchOutcome) child(d decision, res searchResult) *searchResult {
+	if res.stop {
+		return &res
+	}
+	if res.found {
+		b.found = true
+		return nil
+	}
 
-		if dir == DirectionHorizontal {
-			optionAxis = root.across
-			oppositeAxis = root.down
-		} else {
-			optionAxis = root.down
-			oppositeAxis = root.across
-		}
+	config := b.root.config
+	config.failures++
+	if config.restartAfter > 0 && config.failures >= config.restartAfter {
+		return &searchResult{stop: true, restart: true}
+	}
+	if !config.conflictLearning {
+		return nil
+	}
+	path := append(slices.Clone(b.root.path), d)
+	if config.nogoods.learn(path, res.conflict) {
+		config.conflictStats.NogoodsLearned++
+	}
+	if !b.found && !res.conflict.has(b.level) {
+		// This node's decision played no part in the failure, so every sibling would fail the same
+		// way: jump straight back to the latest decision that did.
+		config.conflictStats.Backjumps++
+		return &searchResult{conflict: res.conflict}
+	}
+	b.acc = b.acc.union(res.conflict.without(b.level))
+	return nil
+}
 
-		// Trim situations where horizontal and vertal words are same.
-		for i := range optionAxis {
-			if optionAxis[i].MaxPossibilities() > 1 {
-				continue
-			}
-			if oppositeAxis[i].MaxPossibilities() > 1 {
-				continue
-			}
+func (b *branchOutcome) result() searchResult {
+	if b.found || !b.root.config.conflictLearning {
+		return searchResult{conflict: levelsUpTo(b.level), found: b.found}
+	}
+	return searchResult{conflict: b.acc}
+}
 
-			optA := optionAxis[i].FirstOrNull()
-			oppA := oppositeAxis[i].FirstOrNull()
-			if optA == nil || oppA == nil {
-				return
-			}
-			if slices.Equal(optA.Line, oppA.Line) {
-				return
-			}
-		}
+func iterateAllPossibleGrids(ctx context.Context, root *gridState, index int, dir Direction, yield func(fill) bool) searchResult {
+	if ctx.Err() != nil {
+		return searchResult{stop: true}
+	}
 
-		options := optionAxis[index]
+	level := root.depth()
+	progress := root.config.progress
+	everything := searchResult{conflict: levelsUpTo(level)}
+	// A child that fails a whole-grid check blames every decision, including its own.
+	childEverything := searchResult{conflict: levelsUpTo(level + 1)}
 
-		// The below loop "makes decisions" and recurses. If we already
-		// have one possibility, that means it's already pre-decided.
-		if options.MaxPossibilities() <= 1 {
-			return
-		}
+	var optionAxis, oppositeAxis []primitives.PossibleLines
+	var optionReasons, oppositeReasons []levelSet
 
-		if options.MaxPossibilities() >= 10 {
-			for options.MaxPossibilities() > 1 {
-				c := options.MakeChoice()
+	if dir == DirectionHorizontal {
+		optionAxis = root.across
+		oppositeAxis = root.down
+		optionReasons = root.acrossReasons
+		oppositeReasons = root.downReasons
+	} else {
+		optionAxis = root.down
+		oppositeAxis = root.across
+		optionReasons = root.downReasons
+		oppositeReasons = root.acrossReasons
+	}
 
-				// Clone oppositeAxis into attemptOpposite.
-				attemptOpposite := make([]primitives.PossibleLines, len(oppositeAxis))
-				copy(attemptOpposite, oppositeAxis)
+	// Trim situations where horizontal and vertal words are same.
+	for i := range optionAxis {
+		if optionAxis[i].MaxPossibilities() > 1 {
+			continue
+		}
+		if oppositeAxis[i].MaxPossibilities() > 1 {
+			continue
+		}
 
-				optionFinal := sliceSelectFunc(optionAxis, func(regular primitives.PossibleLines, idx int) primitives.PossibleLines {
-					if idx == index {
-						return c.Choice
-					}
-					return regular
-				})
-
-				// If any word appears more than once, this is not a valid grid.
-				{
-					duplicate := false
-					for k := range attemptOpposite {
-						first := attemptOpposite[k]
-						second := optionFinal[k]
-						if first.MaxPossibilities() > 1 || second.MaxPossibilities() > 1 {
-							continue
-						}
-						f := first.FirstOrNull()
-						s := second.FirstOrNull()
-						if f == nil || s == nil {
-							continue
-						}
-						if slices.Equal(f.Line, s.Line) {
-							duplicate = true
-							break
-
-						}
-					}
-					if duplicate {
-						return
-					}
-				}
+		optA := optionAxis[i].FirstOrNull()
+		oppA := oppositeAxis[i].FirstOrNull()
+		if optA == nil || oppA == nil {
+			progress.prune(PruneImpossibleLine)
+			return everything
+		}
+		if sameLine(optA, oppA) {
+			progress.prune(PruneDuplicateWord)
+			return everything
+		}
+	}
 
-				var newRoot *gridState
-				if dir == DirectionHorizontal {
-					newRoot = &gridState{
-						down:   attemptOpposite,
-						across: optionFinal,
-						rand:   root.rand,
-					}
-				} else {
-					newRoot = &gridState{
-						down:   optionFinal,
-						across: attemptOpposite,
-						rand:   root.rand,
-					}
-				}
+	options := optionAxis[index]
 
-				if numDefiniteBlocks(c.Choice) > numDefiniteBlocks(options) {
-					if isBoardDefinitelyDivided(newRoot) {
-						return
-					}
-				}
-				for final := range possibleGridsAtRoot(ctx, newRoot) {
-					if !yield(final) {
-						return
-					}
-				}
+	// The below loop "makes decisions" and recurses. If we already
+	// have one possibility, that means it's already pre-decided.
+	if options.Decided() {
+		return everything
+	}
 
-				options = c.Remaining
-			}
+	// The children together cover every possibility for this line, so if they all fail, so does
+	// whatever narrowed this line down.
+	outcome := branchOutcome{root: root, level: level, acc: optionReasons[index]}
+
+	// branch counts the children tried so far. When resuming a search, those explored before its
+	// checkpoint are skipped; they may have found grids, so nothing can be learned from this node.
+	trail := root.config.trail
+	branch := -1
+	skip := func() bool {
+		branch++
+		if trail.skip(level, branch) {
+			outcome.found = true
+			return true
+		}
+		trail.enter(level, dir, index, branch)
+		return false
+	}
 
-			if options.MaxPossibilities() == 0 {
-				return
-			}
+	newRoot := func(optionFinal, oppositeFinal []primitives.PossibleLines, optionFinalReasons, oppositeFinalReasons []levelSet, d decision) gridState {
+		var s gridState
+		if dir == DirectionHorizontal {
+			s = root.withLines(oppositeFinal, optionFinal)
+			s.acrossReasons, s.downReasons = optionFinalReasons, oppositeFinalReasons
+		} else {
+			s = root.withLines(optionFinal, oppositeFinal)
+			s.downReasons, s.acrossReasons = optionFinalReasons, oppositeFinalReasons
 		}
+		s.path = append(slices.Clone(root.path), d)
+		return s
+	}
 
-		for attempt := range options.Iterate() {
-			// If any word appears more than once, this is not a valid grid.
-			wordCounts := make(map[string]int)
-			hasDuplicate := false
-			for _, word := range attempt.Words {
-				wordCounts[word]++
-				if wordCounts[word] > 1 {
-					hasDuplicate = true
-				}
-			}
-			if hasDuplicate {
-				continue
+	if options.MaxPossibilities() >= 10 {
+		for options.MaxPossibilities() > 1 {
+			before := options
+			c := splitLine(options)
+			if root.config.randomizeOrder && root.rand.IntN(2) == 0 && !impossible(c.Remaining) {
+				c.Choice, c.Remaining = c.Remaining, c.Choice
 			}
+			d := decision{dir: dir, index: index}
 
 			// Clone oppositeAxis into attemptOpposite.
 			attemptOpposite := make([]primitives.PossibleLines, len(oppositeAxis))
 			copy(attemptOpposite, oppositeAxis)
 
-			for i := range attempt.Line {
-				// WLOG say we dir is Horizontal, and opopsite is Vertical.
-				// we have:
-				//
-				// W O R D
-				// _ _ _ _
-				// _ _ _ _
-				// _ _ _ _
-				//
-				// Then go over each COL (i), filtering s.t. possible lines
-				// only include cases where col[i]'s |attempt|th character == attempt[i].
-				var constriant = attempt.Line[i]
-
-				attemptOpposite[i] = attemptOpposite[i].RemoveWordOptions(attempt.Words).Filter(constriant, index)
-
-				if attemptOpposite[i].MaxPossibilities() == 1 {
-					ao := attemptOpposite[i].FirstOrNull()
-					if ao == nil || slices.Equal(ao.Line, attempt.Line) {
-						return
-					}
+			optionFinal := sliceSelectFunc(optionAxis, func(regular primitives.PossibleLines, idx int) primitives.PossibleLines {
+				if idx == index {
+					return c.Choice
 				}
-			}
+				return regular
+			})
+			optionFinalReasons := slices.Clone(optionReasons)
+			optionFinalReasons[index] = optionFinalReasons[index].with(level)
 
-			if slices.ContainsFunc(attemptOpposite, impossible) {
+			options = c.Remaining
+			if skip() {
 				continue
 			}
 
-			oppositeFinal := attemptOpposite
-			optionFinal := sliceSelectFunc(optionAxis,
-				func(regular primitives.PossibleLines, idx int) primitives.PossibleLines {
-					if idx == index {
-						return primitives.MakeDefinite(attempt)
-					}
-					return regular.RemoveWordOptions(attempt.Words)
-				})
-
+			// If any word appears more than once, this is not a valid grid.
 			{
 				duplicate := false
 				for k := range attemptOpposite {
@@ -629,39 +1117,183 @@ func iterateAllPossibleGrids(ctx context.Context, root *gridState, index int, di
 					if f == nil || s == nil {
 						continue
 					}
-					if slices.Equal(f.Line, s.Line) {
+					if sameLine(f, s) {
 						duplicate = true
 						break
+
 					}
 				}
 				if duplicate {
-					return
+					progress.prune(PruneDuplicateWord)
+					if res := outcome.child(d, childEverything); res != nil {
+						return *res
+					}
+					continue
 				}
 			}
 
-			var newRoot *gridState
-			if dir == DirectionHorizontal {
-				newRoot = &gridState{
-					down:   oppositeFinal,
-					across: optionFinal,
-					rand:   root.rand,
+			next := newRoot(optionFinal, attemptOpposite, optionFinalReasons, oppositeReasons, d)
+
+			if numDefiniteBlocks(c.Choice) > numDefiniteBlocks(before) {
+				if isBoardDefinitelyDivided(&next) {
+					progress.prune(PruneDividedBoard)
+					if res := outcome.child(d, childEverything); res != nil {
+						return *res
+					}
+					continue
 				}
-			} else {
-				newRoot = &gridState{
-					down:   optionFinal,
-					across: oppositeFinal,
-					rand:   root.rand,
+				if breaksStructure(&next, root.config.structure) {
+					progress.prune(PruneStructure)
+					if res := outcome.child(d, childEverything); res != nil {
+						return *res
+					}
+					continue
 				}
 			}
+			if res := outcome.child(d, possibleGridsAtRoot(ctx, &next, yield)); res != nil {
+				return *res
+			}
+		}
 
-			for final := range possibleGridsAtRoot(ctx, newRoot) {
-				if !yield(final) {
-					return
+		if options.MaxPossibilities() == 0 {
+			return outcome.result()
+		}
+	}
+
+	attempts := slices.Collect(options.Iterate())
+	if root.config.randomizeOrder {
+		root.rand.Shuffle(len(attempts), func(i, j int) {
+			attempts[i], attempts[j] = attempts[j], attempts[i]
+		})
+	}
+	for _, attempt := range attempts {
+		if skip() {
+			continue
+		}
+		d := decision{dir: dir, index: index, line: lineKey(&attempt)}
+
+		// If any word appears more than once, this is not a valid grid.
+		wordCounts := make(map[string]int)
+		hasDuplicate := false
+		for _, word := range attempt.Words {
+			key := root.config.root(word)
+			wordCounts[key]++
+			if wordCounts[key] > 1 {
+				hasDuplicate = true
+			}
+		}
+		if hasDuplicate {
+			progress.prune(PruneDuplicateWord)
+			if res := outcome.child(d, searchResult{conflict: levelSet{}.with(level)}); res != nil {
+				return *res
+			}
+			continue
+		}
+
+		// Clone oppositeAxis into attemptOpposite.
+		attemptOpposite := make([]primitives.PossibleLines, len(oppositeAxis))
+		copy(attemptOpposite, oppositeAxis)
+		attemptOppositeReasons := slices.Clone(oppositeReasons)
+		usedWords := root.config.withRelated(attempt.Words)
+
+		var failure *searchResult
+		for i := range attempt.Line {
+			// WLOG say we dir is Horizontal, and opopsite is Vertical.
+			// we have:
+			//
+			// W O R D
+			// _ _ _ _
+			// _ _ _ _
+			// _ _ _ _
+			//
+			// Then go over each COL (i), filtering s.t. possible lines
+			// only include cases where col[i]'s |attempt|th character == attempt[i].
+			var constriant = attempt.Line[i]
+
+			filtered := attemptOpposite[i].RemoveWordOptions(usedWords).Filter(constriant, index)
+			if filtered != attemptOpposite[i] {
+				attemptOpposite[i] = filtered
+				attemptOppositeReasons[i] = attemptOppositeReasons[i].with(level)
+			}
+
+			if impossible(attemptOpposite[i]) {
+				progress.prune(PruneImpossibleLine)
+				failure = &searchResult{conflict: attemptOppositeReasons[i]}
+				break
+			}
+
+			if attemptOpposite[i].MaxPossibilities() == 1 {
+				ao := attemptOpposite[i].FirstOrNull()
+				if ao == nil || sameLine(ao, &attempt) {
+					progress.prune(PruneDuplicateWord)
+					failure = &childEverything
+					break
+				}
+			}
+		}
+		if failure != nil {
+			if res := outcome.child(d, *failure); res != nil {
+				return *res
+			}
+			continue
+		}
+
+		oppositeFinal := attemptOpposite
+		optionFinalReasons := slices.Clone(optionReasons)
+		optionFinal := sliceSelectFunc(optionAxis,
+			func(regular primitives.PossibleLines, idx int) primitives.PossibleLines {
+				if idx == index {
+					optionFinalReasons[idx] = optionFinalReasons[idx].with(level)
+					return primitives.MakeDefinite(attempt)
+				}
+				filtered := regular.RemoveWordOptions(usedWords)
+				if filtered != regular {
+					optionFinalReasons[idx] = optionFinalReasons[idx].with(level)
+				}
+				return filtered
+			})
+
+		{
+			duplicate := false
+			for k := range attemptOpposite {
+				first := attemptOpposite[k]
+				second := optionFinal[k]
+				if first.MaxPossibilities() > 1 || second.MaxPossibilities() > 1 {
+					continue
+				}
+				f := first.FirstOrNull()
+				s := second.FirstOrNull()
+				if f == nil || s == nil {
+					continue
+				}
+				if sameLine(f, s) {
+					duplicate = true
+					break
 				}
 			}
+			if duplicate {
+				progress.prune(PruneDuplicateWord)
+				if res := outcome.child(d, childEverything); res != nil {
+					return *res
+				}
+				continue
+			}
+		}
 
+		next := newRoot(optionFinal, oppositeFinal, optionFinalReasons, attemptOppositeReasons, d)
+		if numDefiniteBlocks(optionFinal[index]) > numDefiniteBlocks(options) && breaksStructure(&next, root.config.structure) {
+			progress.prune(PruneStructure)
+			if res := outcome.child(d, childEverything); res != nil {
+				return *res
+			}
+			continue
+		}
+		if res := outcome.child(d, possibleGridsAtRoot(ctx, &next, yield)); res != nil {
+			return *res
 		}
 	}
+
+	return outcome.result()
 }
 
 func sliceSelectFunc[From any, To any](slice []From, f func(From, int) To) []To {
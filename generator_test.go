@@ -6,8 +6,12 @@ import (
 	"fmt"
 	"math/rand/v2"
 	"os"
+	"slices"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func loadWords(t testing.TB) []string {
@@ -28,12 +32,51 @@ func loadWords(t testing.TB) []string {
 	return words
 }
 
+// testGenerator returns a generator of grids of the given size, with words in a single tier and a
+// fixed seed.
+func testGenerator(size int, words []string, params GeneratorParams) *Generator {
+	return CreateGenerator(size, PreferredAndObscureTiers(words, nil), nil, rand.New(rand.NewPCG(1, 2)), params)
+}
+
+// joinRows returns the Repr of a grid with the given rows.
+func joinRows(rows ...string) string {
+	return strings.Join(rows, "\n")
+}
+
+// checkGrids checks that gen yields exactly the grids in want, in any order. If pattern isn't
+// empty, the grids fill it (see PossibleGridsFrom).
+func checkGrids(t *testing.T, gen *Generator, pattern string, want []string) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+
+	grids := gen.PossibleGrids(ctx)
+	if pattern != "" {
+		var err error
+		if grids, err = gen.PossibleGridsFrom(ctx, mustParseGrid(t, pattern)); err != nil {
+			t.Fatalf("PossibleGridsFrom() = %v", err)
+		}
+	}
+	var got []string
+	for grid := range grids {
+		got = append(got, grid.Repr())
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("search did not end: %v", ctx.Err())
+	}
+	slices.Sort(got)
+	want = slices.Sorted(slices.Values(want))
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("grids mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestPossibleGrids_5x5(t *testing.T) {
 	words := loadWords(t)
 	// Use a fixed seed for reproducibility.
 	rng := rand.New(rand.NewPCG(42, 1024))
 
-	gen := CreateGenerator(5, words, nil, nil, rng, GeneratorParams{
+	gen := CreateGenerator(5, PreferredAndObscureTiers(words, nil), nil, rng, GeneratorParams{
 		MinWordLength: 3,
 	})
 
@@ -61,6 +104,50 @@ func TestPossibleGrids_5x5(t *testing.T) {
 	}
 }
 
+func TestPossibleGrids_TierCaps(t *testing.T) {
+	// The words make four 3x3 grids: two that use both "dev" and "eva", and two that use neither.
+	// Allow at most one entry from the second tier.
+	gen := CreateGenerator(3, []WordTier{
+		{Name: "core", Words: []string{"aca", "ace", "ada", "are", "ipa", "ira", "per", "rec"}, Weight: 1},
+		{Name: "crosswordese", Words: []string{"dev", "eva"}, Weight: 0.2, MaxEntries: 1},
+	}, nil, rand.New(rand.NewPCG(1, 2)), GeneratorParams{MinWordLength: 3})
+
+	checkGrids(t, gen, "", []string{
+		joinRows("ipa", "rec", "are"),
+		joinRows("ira", "per", "ace"),
+	})
+}
+
+func TestPossibleGrids_MaxLowScoreEntries(t *testing.T) {
+	// The words make four 3x3 grids: two that use "eva", and two that use "ira" and "per".
+	tiers := []WordTier{
+		{Name: "core", Words: []string{"aca", "ace", "ada", "are", "dev", "ipa", "rec"}, Weight: 1},
+		{Name: "acceptable", Words: []string{"ira", "per"}, Weight: 0.8},
+		{Name: "crosswordese", Words: []string{"eva"}, Weight: 0.2},
+	}
+	evaGrids := []string{joinRows("ada", "rec", "eva"), joinRows("are", "dev", "aca")}
+	iraGrids := []string{joinRows("ipa", "rec", "are"), joinRows("ira", "per", "ace")}
+
+	for _, tc := range []struct {
+		name      string
+		threshold float64
+		want      []string
+	}{
+		// "ira" and "per" are both low-score, which is one too many.
+		{name: "non-first tiers", threshold: 0, want: evaGrids},
+		{name: "below threshold", threshold: 0.5, want: slices.Concat(evaGrids, iraGrids)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gen := CreateGenerator(3, tiers, nil, rand.New(rand.NewPCG(1, 2)), GeneratorParams{
+				MinWordLength:      3,
+				MaxLowScoreEntries: 1,
+				LowScoreThreshold:  tc.threshold,
+			})
+			checkGrids(t, gen, "", tc.want)
+		})
+	}
+}
+
 func BenchmarkPossibleGrids(b *testing.B) {
 	words := loadWords(b)
 	b.ReportAllocs()
@@ -75,22 +162,51 @@ func BenchmarkPossibleGrids(b *testing.B) {
 		{name: "7x7", sideLength: 7, numBoardsToReturn: 5},
 		{name: "8x8", sideLength: 8, numBoardsToReturn: 5},
 	} {
-		b.Run(tc.name, func(b *testing.B) {
-			rng := rand.New(rand.NewPCG(42, 1024))
-			for b.Loop() {
-				gen := CreateGenerator(tc.sideLength, words, nil, nil, rng, GeneratorParams{
-					MinWordLength: 3,
-				})
-
-				numReturned := 0
-				for range gen.PossibleGrids(b.Context()) {
-					numReturned++
-					if numReturned >= tc.numBoardsToReturn {
-						break
+		for _, mode := range []struct {
+			name      string
+			fixedPass bool
+		}{
+			{name: "fixpoint", fixedPass: false},
+			{name: "fixedpass", fixedPass: true},
+		} {
+			b.Run(tc.name+"/"+mode.name, func(b *testing.B) {
+				rng := rand.New(rand.NewPCG(42, 1024))
+				for b.Loop() {
+					gen := CreateGenerator(tc.sideLength, PreferredAndObscureTiers(words, nil), nil, rng, GeneratorParams{
+						MinWordLength:        3,
+						FixedPassPropagation: mode.fixedPass,
+					})
+
+					numReturned := 0
+					for range gen.PossibleGrids(b.Context()) {
+						numReturned++
+						if numReturned >= tc.numBoardsToReturn {
+							break
+						}
 					}
+					stats := gen.SearchStats().Propagation
+					b.ReportMetric(float64(numReturned), "boards_returned")
+					b.ReportMetric(float64(stats.Propagations), "propagations")
+					b.ReportMetric(float64(stats.Revisions), "revisions")
+					b.ReportMetric(float64(stats.Wipeouts), "wipeouts")
 				}
-				b.ReportMetric(float64(numReturned), "boards_returned")
-			}
+			})
+		}
+	}
+}
+
+func TestPossibleGrids_TriesSiblingsOfRejectedChild(t *testing.T) {
+	// Some children of a node repeat a word or make a row equal to a column. Only those children
+	// are skipped, and the search goes on with their siblings, so both grids are found.
+	words := []string{"epi", "eps", "hes", "het", "sin", "ssn", "tin"}
+	want := []string{"hes\neps\ntin", "het\nepi\nssn"}
+	for _, disable := range []bool{false, true} {
+		t.Run(fmt.Sprintf("DisableConflictLearning=%v", disable), func(t *testing.T) {
+			gen := testGenerator(3, words, GeneratorParams{
+				MinWordLength:           3,
+				DisableConflictLearning: disable,
+			})
+			checkGrids(t, gen, "", want)
 		})
 	}
 }
@@ -10,6 +10,9 @@ import (
 // It represents a 'definite' possible grid
 type Grid struct {
 	grid [][]rune
+	// right[y][x] and below[y][x] are true if the cell at (x, y) has a bar on its right or bottom
+	// side. Both are nil unless the grid is barred.
+	right, below [][]bool
 }
 
 func NewGrid(g [][]rune) Grid {
@@ -18,6 +21,16 @@ func NewGrid(g [][]rune) Grid {
 	}
 }
 
+// NewBarredGrid returns a barred grid, where right[y][x] and below[y][x] are true if the cell at
+// (x, y) has a bar on its right or bottom side.
+func NewBarredGrid(g [][]rune, right, below [][]bool) Grid {
+	return Grid{
+		grid:  g,
+		right: right,
+		below: below,
+	}
+}
+
 func (g Grid) Width() int {
 	return len(g.grid[0])
 }
@@ -30,14 +43,103 @@ func (g Grid) Get(x, y int) rune {
 	return g.grid[y][x]
 }
 
+// Barred returns true if entries of the grid are separated by bars between cells.
+func (g Grid) Barred() bool {
+	return g.right != nil
+}
+
+// BarRight returns true if there is a bar between the cell at (x, y) and the one to its right.
+func (g Grid) BarRight(x, y int) bool {
+	return g.right != nil && g.right[y][x]
+}
+
+// BarBelow returns true if there is a bar between the cell at (x, y) and the one below it.
+func (g Grid) BarBelow(x, y int) bool {
+	return g.below != nil && g.below[y][x]
+}
+
+// Repr returns the grid with one row per line. In barred grids, cells are separated by '|' where
+// there is a bar and a space otherwise, and rows by a line with '-' under every cell with a bar
+// below it.
 func (g Grid) Repr() string {
-	lines := make([]string, g.Height())
+	if !g.Barred() {
+		lines := make([]string, g.Height())
+		for y := range g.Height() {
+			lines[y] = string(g.grid[y])
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	var lines []string
 	for y := range g.Height() {
-		lines[y] = string(g.grid[y])
+		var row, bars strings.Builder
+		for x := range g.Width() {
+			if x > 0 {
+				row.WriteRune(barRune(g.BarRight(x-1, y), '|'))
+				bars.WriteRune(' ')
+			}
+			row.WriteRune(g.grid[y][x])
+			bars.WriteRune(barRune(g.BarBelow(x, y), '-'))
+		}
+		lines = append(lines, row.String())
+		if y+1 < g.Height() {
+			lines = append(lines, strings.TrimRight(bars.String(), " "))
+		}
 	}
 	return strings.Join(lines, "\n")
 }
 
+func barRune(bar bool, r rune) rune {
+	if bar {
+		return r
+	}
+	return ' '
+}
+
 func (g Grid) DebugString() string {
 	return fmt.Sprintf("Grid{width: %d, height: %d, grid: %v}", g.Width(), g.Height(), g.grid)
 }
+
+// Words returns the entries in the grid: every maximal run of two or more letters, ended by blocks
+// or bars, across entries first (top to bottom), then down entries (left to right).
+//
+// In grids with unchecked cells, this includes runs that are too short to be entries. Entries
+// leaves them out.
+func (g Grid) Words() []string {
+	return g.Entries(2)
+}
+
+// Entries returns the maximal runs of at least minLength letters in the grid, in the order of
+// Words. With the minimum word length of the generator, these are the entries of a grid with
+// unchecked cells, where shorter runs are letters that only belong to an entry in the other
+// direction.
+func (g Grid) Entries(minLength int) []string {
+	var words []string
+	// addRuns adds the runs of line, where barAfter(i) is true if there is a bar after cell i.
+	addRuns := func(line []rune, barAfter func(i int) bool) {
+		start := 0
+		for i := range len(line) + 1 {
+			if i < len(line) && line[i] != BlockCell && (i == 0 || !barAfter(i-1)) {
+				continue
+			}
+			if i-start >= max(minLength, 2) {
+				words = append(words, string(line[start:i]))
+			}
+			start = i
+			if i < len(line) && line[i] == BlockCell {
+				start++
+			}
+		}
+	}
+	for y := range g.Height() {
+		addRuns(g.grid[y], func(x int) bool { return g.BarRight(x, y) })
+	}
+	for x := range g.Width() {
+		col := make([]rune, g.Height())
+		for y := range g.Height() {
+			col[y] = g.grid[y][x]
+		}
+		addRuns(col, func(y int) bool { return g.BarBelow(x, y) })
+	}
+	return words
+}
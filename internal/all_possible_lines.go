@@ -3,34 +3,57 @@ package internal
 import (
 	"context"
 	"math/rand/v2"
+	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/Eyas/xwgen/pkg/primitives"
 )
 
 type AllPossibleLinesParams struct {
-	PreferredWords []string
-	ObscureWords   []string
-	ExcludedWords  []string
-	LineLength     int
-	MinWordLength  *int
-	MaxWordLength  *int
+	// Tiers lists the words to use, grouped into tiers in order of preference.
+	Tiers         [][]string
+	ExcludedWords []string
+	// ExcludedPatterns excludes every word matched by any of the patterns.
+	ExcludedPatterns []*regexp.Regexp
+	// ExcludedSubstrings excludes every word containing any of the substrings.
+	ExcludedSubstrings []string
+	LineLength         int
+	MinWordLength      *int
+	MaxWordLength      *int
+	// AllowUnchecked allows runs of letters shorter than MinWordLength, which aren't entries in the
+	// line's direction, as in cryptic grids.
+	AllowUnchecked bool
+	// Barred separates the runs of letters of a line with bars between cells rather than blocks,
+	// as in barred grids.
+	Barred bool
+	// Rand shuffles the order in which blocks or bars are placed. If nil, the global source is used.
+	Rand *rand.Rand
 }
 
 type params struct {
-	preferredWords []string
-	obscureWords   []string
-	excludedWords  []string
-	lineLength     int
-	minWordLength  int
-	maxWordLength  int
+	tiers              [][]string
+	excludedWords      []string
+	excludedPatterns   []*regexp.Regexp
+	excludedSubstrings []string
+	lineLength         int
+	minWordLength      int
+	maxWordLength      int
+	allowUnchecked     bool
+	barred             bool
+	rand               *rand.Rand
 }
 
 func asParams(p AllPossibleLinesParams) params {
 	pp := params{
-		preferredWords: p.PreferredWords,
-		obscureWords:   p.ObscureWords,
-		excludedWords:  p.ExcludedWords,
-		lineLength:     p.LineLength,
+		tiers:              p.Tiers,
+		excludedWords:      p.ExcludedWords,
+		excludedPatterns:   p.ExcludedPatterns,
+		excludedSubstrings: p.ExcludedSubstrings,
+		lineLength:         p.LineLength,
+		allowUnchecked:     p.AllowUnchecked,
+		barred:             p.Barred,
+		rand:               p.Rand,
 	}
 
 	if p.MinWordLength == nil {
@@ -52,13 +75,20 @@ type allPossibleLineState struct {
 	lineLength    int
 	minWordLength int
 	maxWordLength int
+	// minRunLength is the length of the shortest run of letters a line may hold: 1 if unchecked runs
+	// are allowed, minWordLength otherwise.
+	minRunLength int
+	barred       bool
+	rand         *rand.Rand
 
-	preferredWordsByLength map[int][]string
-	obscureWordsByLength   map[int][]string
+	// tieredWordsByLength[length][tier] lists the words of a given length in a given tier.
+	tieredWordsByLength map[int][][]string
 
 	excludedWords map[string]bool
 
-	memoizedLines map[int]primitives.PossibleLines
+	memoizedLines    map[int]primitives.PossibleLines
+	memoizedRuns     map[int]primitives.PossibleLines
+	memoizedLastRuns map[int]primitives.PossibleLines
 }
 
 func (s *allPossibleLineState) allPossibleLines(ctx context.Context, atLength int) primitives.PossibleLines {
@@ -75,45 +105,50 @@ func (s *allPossibleLineState) allPossibleLines(ctx context.Context, atLength in
 		panic("atLength > lineLength -- this should never happen")
 	}
 
-	if atLength < s.minWordLength {
+	if atLength < s.minRunLength {
 		return primitives.MakeImpossible(atLength)
 	}
 
-	words := primitives.MakeWordsFromPreferredAndObscure(s.preferredWordsByLength[atLength], s.obscureWordsByLength[atLength], atLength)
+	if s.barred {
+		return s.barredLines(ctx, atLength)
+	}
+	words := s.run(atLength)
 
+	// Every line is split at its first run, so that it has a single decomposition: a block followed
+	// by any line, a run followed only by blocks, or a run followed by a block and any line.
 	var blockBetweenPossibilities []primitives.PossibleLines
-	// recurse into all combination of [ANYTHING]*[ANYTHING]
+	// recurse into all combination of [RUN]*[ANYTHING]
 	//
 	// For length 10:
 	// 0 1 2 3 4 5 6 7 8 9
 	// _ _ _ _ _ _ _ _ _ _
 	//       ^     ^
 	// Blockage can be anywhere etween idx 3 and len-4 (inclusive).
-	betweenIdxStart := s.minWordLength
-	betweenIdxFromEnd := (1 + s.minWordLength)
+	betweenIdxStart := s.minRunLength
+	betweenIdxFromEnd := (1 + s.minRunLength)
 	if atLength >= (betweenIdxStart + betweenIdxFromEnd) {
-		blockBetweenPossibilities = make([]primitives.PossibleLines, 0, atLength-6)
+		blockBetweenPossibilities = make([]primitives.PossibleLines, 0, atLength-betweenIdxStart-betweenIdxFromEnd+1)
 		for i := betweenIdxStart; i <= atLength-betweenIdxFromEnd; i++ {
-			firstLength := i                   // Always >= minWordLength.
-			secondLength := atLength - (i + 1) // Always >= minWordLength.
+			firstLength := i                   // Always >= minRunLength.
+			secondLength := atLength - (i + 1) // Always >= minRunLength.
 
 			blockBetweenPossibilities = append(blockBetweenPossibilities, primitives.MakeBlockBetween(
-				s.allPossibleLines(ctx, firstLength),
+				s.run(firstLength),
 				s.allPossibleLines(ctx, secondLength),
 			))
 		}
 
 		// Shuffle the possibilities
-		rand.Shuffle(len(blockBetweenPossibilities), func(i, j int) {
+		s.shuffle(len(blockBetweenPossibilities), func(i, j int) {
 			blockBetweenPossibilities[i], blockBetweenPossibilities[j] = blockBetweenPossibilities[j], blockBetweenPossibilities[i]
 		})
 	}
 
-	// recurse into *[ANYTHING], and [ANYTHING]*
+	// recurse into *[ANYTHING], and [RUN]*
 	var blockBefore, blockAfter primitives.PossibleLines
 	if smaller := s.allPossibleLines(ctx, atLength-1); !isImpossible(smaller) {
 		blockBefore = primitives.MakeBlockBefore(smaller)
-		blockAfter = primitives.MakeBlockAfter(smaller)
+		blockAfter = primitives.MakeBlockAfter(s.lastRun(atLength - 1))
 	}
 
 	if blockBefore == nil && blockAfter == nil && len(blockBetweenPossibilities) == 0 {
@@ -137,6 +172,68 @@ func (s *allPossibleLineState) allPossibleLines(ctx context.Context, atLength in
 	return compound
 }
 
+// barredLines returns the lines of a barred grid of the given length: a single run of letters, or
+// a run followed by a bar and any shorter line. Only splitting off the first run gives every
+// arrangement of bars a single decomposition.
+func (s *allPossibleLineState) barredLines(ctx context.Context, atLength int) primitives.PossibleLines {
+	var barBetweenPossibilities []primitives.PossibleLines
+	for i := s.minRunLength; i <= atLength-s.minRunLength; i++ {
+		barBetweenPossibilities = append(barBetweenPossibilities, primitives.MakeBarBetween(
+			s.run(i),
+			s.allPossibleLines(ctx, atLength-i),
+		))
+	}
+	s.shuffle(len(barBetweenPossibilities), func(i, j int) {
+		barBetweenPossibilities[i], barBetweenPossibilities[j] = barBetweenPossibilities[j], barBetweenPossibilities[i]
+	})
+
+	lines := primitives.MakeCompound(append([]primitives.PossibleLines{s.run(atLength)}, barBetweenPossibilities...), atLength)
+	s.memoizedLines[atLength] = lines
+	return lines
+}
+
+// run returns a single run of letters of the given length: an entry, or an unchecked run if it is
+// shorter than minWordLength.
+func (s *allPossibleLineState) run(atLength int) primitives.PossibleLines {
+	if run, ok := s.memoizedRuns[atLength]; ok {
+		return run
+	}
+	var run primitives.PossibleLines
+	if atLength < s.minWordLength {
+		run = primitives.MakeUnchecked(atLength)
+	} else {
+		run = primitives.MakeWordsFromTiers(s.tieredWordsByLength[atLength], atLength)
+	}
+	s.memoizedRuns[atLength] = run
+	return run
+}
+
+// lastRun returns the lines of the given length that hold a single run of letters, followed by any
+// number of blocks.
+func (s *allPossibleLineState) lastRun(atLength int) primitives.PossibleLines {
+	if atLength < s.minRunLength {
+		return primitives.MakeImpossible(atLength)
+	}
+	if lines, ok := s.memoizedLastRuns[atLength]; ok {
+		return lines
+	}
+	lines := s.run(atLength)
+	if shorter := s.lastRun(atLength - 1); !isImpossible(shorter) {
+		lines = primitives.MakeCompound([]primitives.PossibleLines{lines, primitives.MakeBlockAfter(shorter)}, atLength)
+	}
+	s.memoizedLastRuns[atLength] = lines
+	return lines
+}
+
+// shuffle shuffles n elements with the state's source, or the global one if it has none.
+func (s *allPossibleLineState) shuffle(n int, swap func(i, j int)) {
+	if s.rand == nil {
+		rand.Shuffle(n, swap)
+		return
+	}
+	s.rand.Shuffle(n, swap)
+}
+
 // AllPossibleLines returns a set of all possible lines for the given parameters.
 func AllPossibleLines(ctx context.Context, p AllPossibleLinesParams) (primitives.PossibleLines, error) {
 	params := asParams(p)
@@ -144,43 +241,46 @@ func AllPossibleLines(ctx context.Context, p AllPossibleLinesParams) (primitives
 		lineLength:    params.lineLength,
 		minWordLength: params.minWordLength,
 		maxWordLength: params.maxWordLength,
+		minRunLength:  params.minWordLength,
+		barred:        params.barred,
+		rand:          params.rand,
+	}
+	if params.allowUnchecked {
+		state.minRunLength = 1
 	}
 	state.memoizedLines = make(map[int]primitives.PossibleLines)
+	state.memoizedRuns = make(map[int]primitives.PossibleLines)
+	state.memoizedLastRuns = make(map[int]primitives.PossibleLines)
 
-	state.preferredWordsByLength = make(map[int][]string)
-	state.obscureWordsByLength = make(map[int][]string)
+	state.tieredWordsByLength = make(map[int][][]string)
 	state.excludedWords = make(map[string]bool)
 
 	for _, word := range params.excludedWords {
 		state.excludedWords[word] = true
 	}
 
-	for _, word := range params.preferredWords {
-		if len(word) < params.minWordLength || len(word) > params.maxWordLength {
-			continue
-		}
-		if _, ok := state.excludedWords[word]; ok {
-			continue
-		}
-		state.preferredWordsByLength[len(word)] = append(state.preferredWordsByLength[len(word)], word)
-	}
-
-	for _, word := range params.obscureWords {
-		if len(word) < params.minWordLength || len(word) > params.maxWordLength {
-			continue
-		}
-		if _, ok := state.excludedWords[word]; ok {
-			continue
-		}
-		state.obscureWordsByLength[len(word)] = append(state.obscureWordsByLength[len(word)], word)
+	for i := params.minWordLength; i <= params.lineLength; i++ {
+		state.tieredWordsByLength[i] = make([][]string, len(params.tiers))
 	}
 
-	for i := params.minWordLength; i <= params.lineLength; i++ {
-		if _, ok := state.preferredWordsByLength[i]; !ok {
-			state.preferredWordsByLength[i] = []string{}
-		}
-		if _, ok := state.obscureWordsByLength[i]; !ok {
-			state.obscureWordsByLength[i] = []string{}
+	// A word that appears in more than one tier only counts towards the first.
+	seen := make(map[string]bool)
+	for tier, words := range params.tiers {
+		for _, word := range words {
+			if len(word) < params.minWordLength || len(word) > params.maxWordLength || len(word) > params.lineLength {
+				continue
+			}
+			if _, ok := state.excludedWords[word]; ok {
+				continue
+			}
+			if IsExcluded(word, params.excludedPatterns, params.excludedSubstrings) {
+				continue
+			}
+			if seen[word] {
+				continue
+			}
+			seen[word] = true
+			state.tieredWordsByLength[len(word)][tier] = append(state.tieredWordsByLength[len(word)][tier], word)
 		}
 	}
 
@@ -188,6 +288,19 @@ func AllPossibleLines(ctx context.Context, p AllPossibleLinesParams) (primitives
 	return possibleLines, ctx.Err()
 }
 
+// IsExcluded returns true if word matches one of the patterns or contains one of the substrings.
+// Empty substrings are ignored, rather than excluding every word.
+func IsExcluded(word string, patterns []*regexp.Regexp, substrings []string) bool {
+	if slices.ContainsFunc(patterns, func(re *regexp.Regexp) bool {
+		return re.MatchString(word)
+	}) {
+		return true
+	}
+	return slices.ContainsFunc(substrings, func(sub string) bool {
+		return sub != "" && strings.Contains(word, sub)
+	})
+}
+
 func isImpossible(p primitives.PossibleLines) bool {
 	_, isImpossible := p.(*primitives.Impossible)
 	return isImpossible
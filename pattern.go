@@ -0,0 +1,180 @@
+package xwgen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Eyas/xwgen/pkg/primitives"
+)
+
+// Cells of a pattern, i.e. a partially filled Grid. Any other cell holds a letter from 'a' to 'z'.
+const (
+	// BlockCell is a block, in patterns as well as in generated grids.
+	BlockCell = '`'
+	// OpenCell is a cell that must be filled with some letter.
+	OpenCell = '.'
+	// AnyCell is a cell that may be filled with a letter or a block.
+	AnyCell = '?'
+)
+
+// ParseGrid parses a pattern with one row per line. Letters are seeds, '`' or '#' are blocks, '.'
+// are cells that must hold a letter and '?' are cells that may hold a letter or a block. Blank
+// lines are ignored.
+func ParseGrid(s string) (Grid, error) {
+	var rows [][]rune
+	for line := range strings.Lines(s) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		row := []rune(strings.ToLower(line))
+		for x, r := range row {
+			switch {
+			case r == '#':
+				row[x] = BlockCell
+			case r == BlockCell, r == OpenCell, r == AnyCell, r >= 'a' && r <= 'z':
+			default:
+				return Grid{}, fmt.Errorf("row %d: unexpected character %q", len(rows)+1, r)
+			}
+		}
+		if len(rows) > 0 && len(row) != len(rows[0]) {
+			return Grid{}, fmt.Errorf("row %d has %d cells, expected %d", len(rows)+1, len(row), len(rows[0]))
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return Grid{}, fmt.Errorf("empty grid")
+	}
+	return NewGrid(rows), nil
+}
+
+// Cell is the value of a single cell of a grid, at column X and row Y.
+type Cell struct {
+	X, Y  int
+	Value rune
+}
+
+func (c Cell) String() string {
+	return fmt.Sprintf("(%d, %d)=%q", c.X, c.Y, c.Value)
+}
+
+// Slot is an entry of a pattern: a run of non-block cells starting at column X and row Y.
+type Slot struct {
+	Direction Direction
+	X, Y      int
+	Length    int
+	// Pattern holds the cells of the slot in the pattern, e.g. "ab.?".
+	Pattern string
+}
+
+func (s Slot) String() string {
+	dir := "across"
+	if s.Direction == DirectionVertical {
+		dir = "down"
+	}
+	return fmt.Sprintf("%d-%s at (%d, %d): %s", s.Length, dir, s.X, s.Y, s.Pattern)
+}
+
+// PatternSlots returns every slot of pattern, across slots first.
+func PatternSlots(pattern Grid) []Slot {
+	var slots []Slot
+	for _, dir := range []Direction{DirectionHorizontal, DirectionVertical} {
+		for y := range pattern.Height() {
+			for x := range pattern.Width() {
+				if slot, ok := slotAt(pattern, x, y, dir); ok && slot.X == x && slot.Y == y {
+					slots = append(slots, slot)
+				}
+			}
+		}
+	}
+	return slots
+}
+
+// slotAt returns the slot of pattern in the given direction that goes through the cell at column x
+// and row y, and false if there is none, i.e. the cell is a block or its run of non-block cells is
+// shorter than two cells.
+func slotAt(pattern Grid, x, y int, dir Direction) (Slot, bool) {
+	open := func(x, y int) bool {
+		return x >= 0 && y >= 0 && x < pattern.Width() && y < pattern.Height() && pattern.Get(x, y) != BlockCell
+	}
+	if !open(x, y) {
+		return Slot{}, false
+	}
+
+	dx, dy := 1, 0
+	if dir == DirectionVertical {
+		dx, dy = 0, 1
+	}
+	for open(x-dx, y-dy) {
+		x, y = x-dx, y-dy
+	}
+	slot := Slot{Direction: dir, X: x, Y: y}
+	var cells []rune
+	for ; open(x, y); x, y = x+dx, y+dy {
+		cells = append(cells, pattern.Get(x, y))
+	}
+	slot.Length = len(cells)
+	slot.Pattern = string(cells)
+	return slot, slot.Length >= 2
+}
+
+// cells returns the positions of the slot's cells, as cells with the given values.
+func (s Slot) cells(values []rune) []Cell {
+	dx, dy := 1, 0
+	if s.Direction == DirectionVertical {
+		dx, dy = 0, 1
+	}
+	cells := make([]Cell, s.Length)
+	for i := range s.Length {
+		cells[i] = Cell{X: s.X + i*dx, Y: s.Y + i*dy, Value: values[i]}
+	}
+	return cells
+}
+
+// patternCells returns the cells of pattern that constrain a fill, i.e. all but AnyCell.
+func patternCells(pattern Grid) []Cell {
+	var cells []Cell
+	for y := range pattern.Height() {
+		for x := range pattern.Width() {
+			if v := pattern.Get(x, y); v != AnyCell {
+				cells = append(cells, Cell{X: x, Y: y, Value: v})
+			}
+		}
+	}
+	return cells
+}
+
+// checkPattern returns an error if pattern can't be filled by a generator for grids of the given
+// size.
+func checkPattern(pattern Grid, lineLength int) error {
+	if pattern.Width() != lineLength || pattern.Height() != lineLength {
+		return fmt.Errorf("pattern is %dx%d, expected %dx%d", pattern.Width(), pattern.Height(), lineLength, lineLength)
+	}
+	return nil
+}
+
+// allowedChars returns the characters a fill may have in a cell with the given value.
+func allowedChars(value rune) *primitives.CharSet {
+	allowed := primitives.NewCharSet()
+	switch value {
+	case AnyCell:
+		allowed.Add(BlockCell)
+		fallthrough
+	case OpenCell:
+		for r := 'a'; r <= 'z'; r++ {
+			allowed.Add(r)
+		}
+	default:
+		allowed.Add(value)
+	}
+	return allowed
+}
+
+// applyCells narrows down the lines of the state to those that agree with the given cells.
+func (s *gridState) applyCells(cells []Cell) {
+	for _, c := range cells {
+		allowed := allowedChars(c.Value)
+		s.across[c.Y] = s.across[c.Y].FilterAny(allowed, c.X)
+		s.down[c.X] = s.down[c.X].FilterAny(allowed, c.Y)
+	}
+}
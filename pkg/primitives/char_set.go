@@ -98,6 +98,17 @@ func (c CharSet) Intersects(other CharSet) bool {
 	return c.bits&other.bits != 0
 }
 
+// runes returns the characters in the set, in order.
+func (c CharSet) runes() []rune {
+	var runes []rune
+	for i := range uint(numChars) {
+		if c.bits&(1<<i) != 0 {
+			runes = append(runes, rune(minChar+i))
+		}
+	}
+	return runes
+}
+
 // String returns a string representation of the set.
 func (c *CharSet) String() string {
 	if c.bits == 0 {
@@ -1,11 +1,17 @@
 package primitives
 
-import "strings"
+import (
+	"slices"
+	"strings"
+)
 
 // ConcreteLine represents a single possible line in a puzzle.
 type ConcreteLine struct {
 	Line  []rune
 	Words []string
+	// Bars lists, in barred grids, the indexes of the cells that have a bar before them, ending the
+	// entry on the other side. It is empty for lines without bars.
+	Bars []int
 }
 
 // Length returns the length of the line.
@@ -16,3 +22,16 @@ func (l *ConcreteLine) Length() int {
 func (l *ConcreteLine) String() string {
 	return strings.ToUpper(string(l.Line))
 }
+
+// joinBars returns the bars of a line made of a part with the bars first, followed by a part with
+// the bars second that starts at index offset.
+func joinBars(first, second []int, offset int) []int {
+	if len(first) == 0 && len(second) == 0 {
+		return nil
+	}
+	bars := slices.Clone(first)
+	for _, b := range second {
+		bars = append(bars, b+offset)
+	}
+	return bars
+}
@@ -3,6 +3,7 @@ package primitives
 import (
 	"fmt"
 	"iter"
+	"math"
 	"slices"
 	"strings"
 )
@@ -32,6 +33,12 @@ type PossibleLines interface {
 	// This can be lower since some lines might include repeated words, etc.
 	MaxPossibilities() int64
 
+	// Decided returns true if no more choices are needed to settle the line: it has at most one
+	// possibility once the letters of its unchecked runs, which the crossing entries decide, are set
+	// aside. MakeChoice only splits the letters of unchecked runs once the rest of the line is
+	// decided.
+	Decided() bool
+
 	// CharsAt adds the characters that can appear at a given index to the given set.
 	CharsAt(accumulate *CharSet, index int)
 
@@ -82,6 +89,10 @@ func (i *Impossible) MaxPossibilities() int64 {
 	return 0
 }
 
+func (i *Impossible) Decided() bool {
+	return true
+}
+
 func (i *Impossible) CharsAt(accumulate *CharSet, index int) {
 }
 
@@ -123,7 +134,12 @@ func (i *Impossible) String() string {
 
 var ic = make([]Impossible, 25)
 
+// MakeImpossible returns an Impossible line of the given length. Lines shorter than 25 letters
+// share a cached value.
 func MakeImpossible(numLetters int) *Impossible {
+	if numLetters >= len(ic) {
+		return &Impossible{numLetters: numLetters}
+	}
 	if ic[numLetters] == (Impossible{}) {
 		ic[numLetters] = Impossible{numLetters: numLetters}
 	}
@@ -132,30 +148,43 @@ func MakeImpossible(numLetters int) *Impossible {
 
 // Words represents a set of possible lines that are exactly filled with any one of the given words.
 //
-// Each word in 'Words' is exactly the same length, fully occupying the line.
+// Each word in 'Words' is exactly the same length, fully occupying the line. Words are grouped into
+// ordered tiers (e.g. preferred, then obscure), and are kept in tier order.
 type Words struct {
-	allWords   []string // All words, starting with preferred, then obscure
-	obscureIdx int      // Index of first obscure word, if 0, all words are obscure, if len(allWords), all words are preferred
+	allWords []string // All words, starting with the first tier, then the second, etc.
+	tierEnds []int    // tierEnds[t] is the index one past the last word in tier t. Tiers may be empty.
 	// letterMasks caches, for each index, the bitmask of allowed runes across all words.
 	// It accelerates CharsAt and lets FilterAny early-return.
 	letterMasks []CharSet
 }
 
 func MakeWordsFromPreferredAndObscure(preferred, obscure []string, numLetters int) PossibleLines {
-	if len(preferred) == 0 && len(obscure) == 0 {
-		return MakeImpossible(numLetters)
-	}
-	if len(preferred) == 1 && len(obscure) == 0 {
-		return MakeDefinite(ConcreteLine{Line: []rune(preferred[0]), Words: []string{preferred[0]}})
+	return MakeWordsFromTiers([][]string{preferred, obscure}, numLetters)
+}
+
+// MakeWordsFromTiers creates a set of possible lines from words grouped into tiers, in order of
+// preference.
+func MakeWordsFromTiers(tiers [][]string, numLetters int) PossibleLines {
+	total := 0
+	for _, tier := range tiers {
+		total += len(tier)
 	}
-	if len(preferred) == 0 && len(obscure) == 1 {
-		return MakeDefinite(ConcreteLine{Line: []rune(obscure[0]), Words: []string{obscure[0]}})
+	allWords := make([]string, 0, total)
+	tierEnds := make([]int, len(tiers))
+	for t, tier := range tiers {
+		allWords = append(allWords, tier...)
+		tierEnds[t] = len(allWords)
 	}
-	// Lazily allocate letterMasks on first use to avoid upfront cost when not needed.
-	return &Words{allWords: append(preferred, obscure...), obscureIdx: len(preferred)}
+	return makeTieredWords(allWords, tierEnds, numLetters)
 }
 
+// MakeWords creates a set of possible lines from a preferred and obscure tier, where allWords[:obscureIdx]
+// are preferred and allWords[obscureIdx:] are obscure.
 func MakeWords(allWords []string, obscureIdx int, numLetters int) PossibleLines {
+	return makeTieredWords(allWords, []int{obscureIdx, len(allWords)}, numLetters)
+}
+
+func makeTieredWords(allWords []string, tierEnds []int, numLetters int) PossibleLines {
 	if len(allWords) == 0 {
 		return MakeImpossible(numLetters)
 	}
@@ -163,7 +192,34 @@ func MakeWords(allWords []string, obscureIdx int, numLetters int) PossibleLines
 		return MakeDefinite(ConcreteLine{Line: []rune(allWords[0]), Words: []string{allWords[0]}})
 	}
 	// Lazily allocate letterMasks on first use to avoid upfront cost when not needed.
-	return &Words{allWords: allWords, obscureIdx: obscureIdx}
+	return &Words{allWords: allWords, tierEnds: tierEnds}
+}
+
+// filterWords returns the words for which keep returns true, preserving tiers.
+func (w *Words) filterWords(keep func(word string) bool) PossibleLines {
+	var filtered []string
+	newTierEnds := make([]int, len(w.tierEnds))
+	tier := 0
+	for idx, word := range w.allWords {
+		for tier < len(w.tierEnds) && idx >= w.tierEnds[tier] {
+			newTierEnds[tier] = len(filtered)
+			tier++
+		}
+		if !keep(word) {
+			continue
+		}
+		// Lazy: allocate filtered list with capacity of allWords-idx only if we
+		// get here.
+		if filtered == nil {
+			filtered = make([]string, 0, len(w.allWords)-idx)
+		}
+		filtered = append(filtered, word)
+	}
+	for ; tier < len(w.tierEnds); tier++ {
+		newTierEnds[tier] = len(filtered)
+	}
+
+	return makeTieredWords(filtered, newTierEnds, w.NumLetters())
 }
 
 func (w *Words) NumLetters() int {
@@ -174,6 +230,10 @@ func (w *Words) MaxPossibilities() int64 {
 	return int64(len(w.allWords))
 }
 
+func (w *Words) Decided() bool {
+	return len(w.allWords) <= 1
+}
+
 func (w *Words) CharsAt(accumulate *CharSet, index int) {
 	if accumulate.IsFull() || (!accumulate.Contains(kBlocked) && (accumulate.Count()+1) == accumulate.Capacity()) {
 		return
@@ -224,21 +284,9 @@ func (w *Words) FilterAny(constraint *CharSet, index int) PossibleLines {
 		return w
 	}
 
-	var filtered []string
-	var newNumPreferred int
-	for idx, word := range w.allWords {
-		if constraint.Contains(rune(word[index])) {
-			if idx < w.obscureIdx {
-				newNumPreferred++
-			}
-			if filtered == nil {
-				filtered = make([]string, 0, len(w.allWords)-idx)
-			}
-			filtered = append(filtered, word)
-		}
-	}
-
-	return MakeWords(filtered, newNumPreferred, w.NumLetters())
+	return w.filterWords(func(word string) bool {
+		return constraint.Contains(rune(word[index]))
+	})
 }
 
 func (w *Words) Filter(constraint rune, index int) PossibleLines {
@@ -257,23 +305,9 @@ func (w *Words) Filter(constraint rune, index int) PossibleLines {
 		}
 	}
 
-	var filtered []string
-	newNumPreferred := 0
-	for idx, word := range w.allWords {
-		if rune(word[index]) == constraint {
-			if idx < w.obscureIdx {
-				newNumPreferred++
-			}
-			// Lazy: allocate filtered list with capacity of allWords-idx only if we
-			// get here.
-			if filtered == nil {
-				filtered = make([]string, 0, len(w.allWords)-idx)
-			}
-			filtered = append(filtered, word)
-		}
-	}
-
-	return MakeWords(filtered, newNumPreferred, w.NumLetters())
+	return w.filterWords(func(word string) bool {
+		return rune(word[index]) == constraint
+	})
 }
 
 func (w *Words) RemoveWordOptions(words []string) PossibleLines {
@@ -290,20 +324,9 @@ func (w *Words) RemoveWordOptions(words []string) PossibleLines {
 		return w
 	}
 
-	var fp []string
-	fPreferred := 0
-
-	fp = make([]string, 0, len(w.allWords)-1)
-	for idx, p := range w.allWords {
-		if !slices.Contains(words, p) {
-			fp = append(fp, p)
-			if idx < w.obscureIdx {
-				fPreferred++
-			}
-		}
-	}
-
-	return MakeWords(fp, fPreferred, w.NumLetters())
+	return w.filterWords(func(word string) bool {
+		return !slices.Contains(words, word)
+	})
 }
 
 func (w *Words) FirstOrNull() *ConcreteLine {
@@ -328,20 +351,19 @@ func (w *Words) MakeChoice() ChoiceStep {
 		panic("Cannot call MakeChoice on entity with 1 or less options")
 	}
 
-	// Simply split allWords in half, and adjust obscureIdx accordingly.
-	w1, w2 := w.allWords[:len(w.allWords)/2], w.allWords[len(w.allWords)/2:]
-	var w1Idx, w2Idx int
-	if w.obscureIdx < len(w1) {
-		w1Idx = w.obscureIdx
-		w2Idx = 0
-	} else {
-		w1Idx = len(w1)
-		w2Idx = w.obscureIdx - len(w1)
+	// Simply split allWords in half, and adjust tierEnds accordingly.
+	mid := len(w.allWords) / 2
+	w1, w2 := w.allWords[:mid], w.allWords[mid:]
+	w1Ends := make([]int, len(w.tierEnds))
+	w2Ends := make([]int, len(w.tierEnds))
+	for t, end := range w.tierEnds {
+		w1Ends[t] = min(end, mid)
+		w2Ends[t] = max(end-mid, 0)
 	}
 
 	return ChoiceStep{
-		Choice:    MakeWords(w1, w1Idx, w.NumLetters()),
-		Remaining: MakeWords(w2, w2Idx, w.NumLetters()),
+		Choice:    makeTieredWords(w1, w1Ends, w.NumLetters()),
+		Remaining: makeTieredWords(w2, w2Ends, w.NumLetters()),
 	}
 }
 
@@ -359,8 +381,24 @@ func arrayStr(arr []string) string {
 	return fmt.Sprintf("[%s, ...%d]", strings.Join(print, ", "), len(rest))
 }
 
+// Tiers returns the words in each tier, in order of preference.
+func (w *Words) Tiers() [][]string {
+	tiers := make([][]string, len(w.tierEnds))
+	start := 0
+	for t, end := range w.tierEnds {
+		tiers[t] = w.allWords[start:end]
+		start = end
+	}
+	return tiers
+}
+
 func (w *Words) String() string {
-	return fmt.Sprintf("Words(%s, %s)", arrayStr(w.allWords[0:w.obscureIdx]), arrayStr(w.allWords[w.obscureIdx:]))
+	tiers := w.Tiers()
+	strs := make([]string, len(tiers))
+	for t, tier := range tiers {
+		strs[t] = arrayStr(tier)
+	}
+	return fmt.Sprintf("Words(%s)", strings.Join(strs, ", "))
 }
 
 // BlockBefore represents a line that has a blocked cell at the beginning.
@@ -383,6 +421,10 @@ func (b *BlockBefore) MaxPossibilities() int64 {
 	return b.lines.MaxPossibilities()
 }
 
+func (b *BlockBefore) Decided() bool {
+	return b.lines.Decided()
+}
+
 func (b *BlockBefore) CharsAt(accumulate *CharSet, index int) {
 	if accumulate.IsFull() {
 		return
@@ -448,7 +490,7 @@ func (b *BlockBefore) FirstOrNull() *ConcreteLine {
 	if c == nil {
 		return nil
 	}
-	return &ConcreteLine{Line: append([]rune{kBlocked}, c.Line...), Words: c.Words}
+	return &ConcreteLine{Line: append([]rune{kBlocked}, c.Line...), Words: c.Words, Bars: joinBars(nil, c.Bars, 1)}
 }
 
 func (b *BlockBefore) MakeChoice() ChoiceStep {
@@ -462,7 +504,7 @@ func (b *BlockBefore) MakeChoice() ChoiceStep {
 func (b *BlockBefore) Iterate() iter.Seq[ConcreteLine] {
 	return func(yield func(ConcreteLine) bool) {
 		for line := range b.lines.Iterate() {
-			if !yield(ConcreteLine{Line: append([]rune{kBlocked}, line.Line...), Words: line.Words}) {
+			if !yield(ConcreteLine{Line: append([]rune{kBlocked}, line.Line...), Words: line.Words, Bars: joinBars(nil, line.Bars, 1)}) {
 				return
 			}
 		}
@@ -493,6 +535,10 @@ func (b *BlockAfter) MaxPossibilities() int64 {
 	return b.lines.MaxPossibilities()
 }
 
+func (b *BlockAfter) Decided() bool {
+	return b.lines.Decided()
+}
+
 func (b *BlockAfter) CharsAt(accumulate *CharSet, index int) {
 	if accumulate.IsFull() {
 		return
@@ -558,13 +604,13 @@ func (b *BlockAfter) FirstOrNull() *ConcreteLine {
 	if c == nil {
 		return nil
 	}
-	return &ConcreteLine{Line: append(c.Line, kBlocked), Words: c.Words}
+	return &ConcreteLine{Line: append(c.Line, kBlocked), Words: c.Words, Bars: c.Bars}
 }
 
 func (b *BlockAfter) Iterate() iter.Seq[ConcreteLine] {
 	return func(yield func(ConcreteLine) bool) {
 		for line := range b.lines.Iterate() {
-			if !yield(ConcreteLine{Line: append(line.Line, kBlocked), Words: line.Words}) {
+			if !yield(ConcreteLine{Line: append(line.Line, kBlocked), Words: line.Words, Bars: line.Bars}) {
 				return
 			}
 		}
@@ -604,6 +650,10 @@ func (b *BlockBetween) MaxPossibilities() int64 {
 	return b.first.MaxPossibilities() * b.second.MaxPossibilities()
 }
 
+func (b *BlockBetween) Decided() bool {
+	return b.first.Decided() && b.second.Decided()
+}
+
 func (b *BlockBetween) CharsAt(accumulate *CharSet, index int) {
 	if accumulate.IsFull() {
 		return
@@ -693,7 +743,11 @@ func (b *BlockBetween) FirstOrNull() *ConcreteLine {
 	if f == nil || s == nil {
 		return nil
 	}
-	return &ConcreteLine{Line: append(append(f.Line, kBlocked), s.Line...), Words: append(f.Words, s.Words...)}
+	return &ConcreteLine{
+		Line:  append(append(f.Line, kBlocked), s.Line...),
+		Words: append(f.Words, s.Words...),
+		Bars:  joinBars(f.Bars, s.Bars, len(f.Line)+1),
+	}
 }
 
 func (b *BlockBetween) Iterate() iter.Seq[ConcreteLine] {
@@ -703,6 +757,7 @@ func (b *BlockBetween) Iterate() iter.Seq[ConcreteLine] {
 				if !yield(ConcreteLine{
 					Line:  append(append(first.Line, kBlocked), second.Line...),
 					Words: append(first.Words, second.Words...),
+					Bars:  joinBars(first.Bars, second.Bars, len(first.Line)+1),
 				}) {
 					return
 				}
@@ -711,8 +766,10 @@ func (b *BlockBetween) Iterate() iter.Seq[ConcreteLine] {
 	}
 }
 
+// MakeChoice splits the part before the block or the part after it: the one with more
+// possibilities, unless it is already decided.
 func (b *BlockBetween) MakeChoice() ChoiceStep {
-	if b.first.MaxPossibilities() > b.second.MaxPossibilities() {
+	if b.second.Decided() || (!b.first.Decided() && b.first.MaxPossibilities() > b.second.MaxPossibilities()) {
 		firstChoice := b.first.MakeChoice()
 		return ChoiceStep{
 			Choice:    &BlockBetween{first: firstChoice.Choice, second: b.second},
@@ -731,6 +788,292 @@ func (b *BlockBetween) String() string {
 	return fmt.Sprintf("BlockBetween(%s, %s)", b.first.String(), b.second.String())
 }
 
+// BarBetween represents a line made of two parts separated by a bar, as in barred grids. Unlike
+// BlockBetween, the bar sits between two cells rather than taking up one.
+type BarBetween struct {
+	first  PossibleLines
+	second PossibleLines
+}
+
+func MakeBarBetween(first, second PossibleLines) PossibleLines {
+	if isImpossible(first) || isImpossible(second) {
+		return MakeImpossible(first.NumLetters() + second.NumLetters())
+	}
+	return &BarBetween{first: first, second: second}
+}
+
+func (b *BarBetween) NumLetters() int {
+	return b.first.NumLetters() + b.second.NumLetters()
+}
+
+func (b *BarBetween) MaxPossibilities() int64 {
+	return b.first.MaxPossibilities() * b.second.MaxPossibilities()
+}
+
+func (b *BarBetween) Decided() bool {
+	return b.first.Decided() && b.second.Decided()
+}
+
+func (b *BarBetween) CharsAt(accumulate *CharSet, index int) {
+	if accumulate.IsFull() {
+		return
+	}
+	if index < b.first.NumLetters() {
+		b.first.CharsAt(accumulate, index)
+	} else {
+		b.second.CharsAt(accumulate, index-b.first.NumLetters())
+	}
+}
+
+func (b *BarBetween) DefinitelyBlockedAt(index int) bool {
+	if index < b.first.NumLetters() {
+		return b.first.DefinitelyBlockedAt(index)
+	}
+	return b.second.DefinitelyBlockedAt(index - b.first.NumLetters())
+}
+
+func (b *BarBetween) build(first, second PossibleLines) PossibleLines {
+	if isImpossible(first) || isImpossible(second) {
+		return MakeImpossible(b.NumLetters())
+	}
+	if first == b.first && second == b.second {
+		return b
+	}
+	return &BarBetween{first: first, second: second}
+}
+
+func (b *BarBetween) DefiniteWords() []string {
+	return append(b.first.DefiniteWords(), b.second.DefiniteWords()...)
+}
+
+func (b *BarBetween) FilterAny(constraint *CharSet, index int) PossibleLines {
+	if constraint.IsFull() {
+		return b
+	}
+	if index < b.first.NumLetters() {
+		return b.build(b.first.FilterAny(constraint, index), b.second)
+	}
+	return b.build(b.first, b.second.FilterAny(constraint, index-b.first.NumLetters()))
+}
+
+func (b *BarBetween) Filter(constraint rune, index int) PossibleLines {
+	if index < b.first.NumLetters() {
+		return b.build(b.first.Filter(constraint, index), b.second)
+	}
+	return b.build(b.first, b.second.Filter(constraint, index-b.first.NumLetters()))
+}
+
+func (b *BarBetween) RemoveWordOptions(words []string) PossibleLines {
+	return b.build(b.first.RemoveWordOptions(words), b.second.RemoveWordOptions(words))
+}
+
+// join returns the line made of first, a bar, then second.
+func (b *BarBetween) join(first, second ConcreteLine) ConcreteLine {
+	return ConcreteLine{
+		Line:  slices.Concat(first.Line, second.Line),
+		Words: slices.Concat(first.Words, second.Words),
+		Bars:  joinBars(slices.Concat(first.Bars, []int{len(first.Line)}), second.Bars, len(first.Line)),
+	}
+}
+
+func (b *BarBetween) FirstOrNull() *ConcreteLine {
+	f := b.first.FirstOrNull()
+	s := b.second.FirstOrNull()
+	if f == nil || s == nil {
+		return nil
+	}
+	line := b.join(*f, *s)
+	return &line
+}
+
+func (b *BarBetween) Iterate() iter.Seq[ConcreteLine] {
+	return func(yield func(ConcreteLine) bool) {
+		for first := range b.first.Iterate() {
+			for second := range b.second.Iterate() {
+				if !yield(b.join(first, second)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// MakeChoice splits the part before the bar or the part after it, picked as in
+// BlockBetween.MakeChoice.
+func (b *BarBetween) MakeChoice() ChoiceStep {
+	if b.second.Decided() || (!b.first.Decided() && b.first.MaxPossibilities() > b.second.MaxPossibilities()) {
+		firstChoice := b.first.MakeChoice()
+		return ChoiceStep{
+			Choice:    &BarBetween{first: firstChoice.Choice, second: b.second},
+			Remaining: &BarBetween{first: firstChoice.Remaining, second: b.second},
+		}
+	}
+
+	secondChoice := b.second.MakeChoice()
+	return ChoiceStep{
+		Choice:    &BarBetween{first: b.first, second: secondChoice.Choice},
+		Remaining: &BarBetween{first: b.first, second: secondChoice.Remaining},
+	}
+}
+
+func (b *BarBetween) String() string {
+	return fmt.Sprintf("BarBetween(%s, %s)", b.first.String(), b.second.String())
+}
+
+// Unchecked represents a run of letters that isn't an entry, e.g. in cryptic grids where a run
+// shorter than the minimum word length is only made of letters checked by crossing entries. Any
+// letter may appear in each cell, independently of the others.
+type Unchecked struct {
+	letters []CharSet
+}
+
+// MakeUnchecked returns a run of numLetters cells that may each hold any letter.
+func MakeUnchecked(numLetters int) PossibleLines {
+	if numLetters == 0 {
+		return MakeImpossible(0)
+	}
+	var anyLetter CharSet
+	for r := 'a'; r <= 'z'; r++ {
+		anyLetter.Add(r)
+	}
+	letters := make([]CharSet, numLetters)
+	for i := range letters {
+		letters[i] = anyLetter
+	}
+	return &Unchecked{letters: letters}
+}
+
+func (u *Unchecked) NumLetters() int {
+	return len(u.letters)
+}
+
+// MaxPossibilities returns the number of ways to fill the run, up to math.MaxInt64.
+func (u *Unchecked) MaxPossibilities() int64 {
+	n := int64(1)
+	for _, l := range u.letters {
+		count := int64(l.Count())
+		if count != 0 && n > math.MaxInt64/count {
+			return math.MaxInt64
+		}
+		n *= count
+	}
+	return n
+}
+
+// Decided returns true: the letters of an unchecked run are decided by the crossing entries.
+func (u *Unchecked) Decided() bool {
+	return true
+}
+
+func (u *Unchecked) CharsAt(accumulate *CharSet, index int) {
+	accumulate.AddAll(&u.letters[index])
+}
+
+func (u *Unchecked) DefinitelyBlockedAt(index int) bool {
+	return false
+}
+
+func (u *Unchecked) DefiniteWords() []string {
+	return nil
+}
+
+// with returns the run with the letters at index narrowed down to letters.
+func (u *Unchecked) with(index int, letters CharSet) PossibleLines {
+	if letters == u.letters[index] {
+		return u
+	}
+	if letters.Count() == 0 {
+		return MakeImpossible(u.NumLetters())
+	}
+	narrowed := slices.Clone(u.letters)
+	narrowed[index] = letters
+	return &Unchecked{letters: narrowed}
+}
+
+func (u *Unchecked) FilterAny(constraint *CharSet, index int) PossibleLines {
+	if constraint.IsFull() {
+		return u
+	}
+	letters := u.letters[index]
+	letters.Intersect(constraint)
+	return u.with(index, letters)
+}
+
+func (u *Unchecked) Filter(constraint rune, index int) PossibleLines {
+	var letters CharSet
+	if u.letters[index].Contains(constraint) {
+		letters.Add(constraint)
+	}
+	return u.with(index, letters)
+}
+
+func (u *Unchecked) RemoveWordOptions(words []string) PossibleLines {
+	return u
+}
+
+func (u *Unchecked) FirstOrNull() *ConcreteLine {
+	line := make([]rune, len(u.letters))
+	for i, l := range u.letters {
+		runes := l.runes()
+		if len(runes) == 0 {
+			return nil
+		}
+		line[i] = runes[0]
+	}
+	return &ConcreteLine{Line: line}
+}
+
+func (u *Unchecked) Iterate() iter.Seq[ConcreteLine] {
+	return func(yield func(ConcreteLine) bool) {
+		line := make([]rune, len(u.letters))
+		var fill func(i int) bool
+		fill = func(i int) bool {
+			if i == len(line) {
+				return yield(ConcreteLine{Line: slices.Clone(line)})
+			}
+			for _, r := range u.letters[i].runes() {
+				line[i] = r
+				if !fill(i + 1) {
+					return false
+				}
+			}
+			return true
+		}
+		fill(0)
+	}
+}
+
+func (u *Unchecked) MakeChoice() ChoiceStep {
+	// Split the letters of the first undecided cell in half.
+	for i, l := range u.letters {
+		runes := l.runes()
+		if len(runes) <= 1 {
+			continue
+		}
+		var first, second CharSet
+		for j, r := range runes {
+			if j < len(runes)/2 {
+				first.Add(r)
+			} else {
+				second.Add(r)
+			}
+		}
+		return ChoiceStep{
+			Choice:    u.with(i, first),
+			Remaining: u.with(i, second),
+		}
+	}
+	panic("Cannot call MakeChoice on entity with 1 or less options")
+}
+
+func (u *Unchecked) String() string {
+	cells := make([]string, len(u.letters))
+	for i, l := range u.letters {
+		cells[i] = string(l.runes())
+	}
+	return fmt.Sprintf("Unchecked(%s)", strings.Join(cells, "|"))
+}
+
 // Compound represents a set of possible lines that are the union of the given sets.
 type Compound struct {
 	possibilities []PossibleLines
@@ -795,6 +1138,10 @@ func (c *Compound) MaxPossibilities() int64 {
 	return sum
 }
 
+func (c *Compound) Decided() bool {
+	return c.MaxPossibilities() <= 1
+}
+
 func (c *Compound) CharsAt(accumulate *CharSet, index int) {
 	for _, p := range c.possibilities {
 		p.CharsAt(accumulate, index)
@@ -905,9 +1252,9 @@ func (c *Compound) RemoveWordOptions(words []string) PossibleLines {
 		if f != p && !anyChanged {
 			// We are the first to change.
 			anyChanged = true
-			if i > 0 {
-				maybeFiltered = c.possibilities[:i]
-			}
+			// Copy the unchanged possibilities, as appending to a subslice of c.possibilities would
+			// overwrite the rest of them.
+			maybeFiltered = slices.Clone(c.possibilities[:i])
 		}
 
 		if !isImpossible(f) {
@@ -996,6 +1343,10 @@ func (d *Definite) MaxPossibilities() int64 {
 	return 1
 }
 
+func (d *Definite) Decided() bool {
+	return true
+}
+
 func (d *Definite) CharsAt(accumulate *CharSet, index int) {
 	accumulate.Add(rune(d.line.Line[index]))
 }
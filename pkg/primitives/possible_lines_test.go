@@ -103,6 +103,12 @@ func TestImpossible(t *testing.T) {
 	})
 }
 
+func TestMakeImpossible_Long(t *testing.T) {
+	if got := MakeImpossible(45).NumLetters(); got != 45 {
+		t.Errorf("MakeImpossible(45).NumLetters() = %d, want 45", got)
+	}
+}
+
 func TestWords_FilterAny(t *testing.T) {
 	p1 := MakeWordsFromPreferredAndObscure([]string{"ab"}, []string{}, 2)
 	p2 := MakeWordsFromPreferredAndObscure([]string{"ac"}, []string{}, 2)
@@ -415,6 +421,53 @@ func TestWords(t *testing.T) {
 	})
 }
 
+func TestWords_Tiers(t *testing.T) {
+	pl := MakeWordsFromTiers([][]string{{"cat", "car"}, {}, {"cot", "cop"}, {"cut"}}, 3)
+	words, ok := pl.(*Words)
+	if !ok {
+		t.Fatalf("MakeWordsFromTiers should return Words, got %T", pl)
+	}
+
+	tiersOf := func(pl PossibleLines) [][]string {
+		w, ok := pl.(*Words)
+		if !ok {
+			t.Fatalf("expected Words, got %T", pl)
+		}
+		return w.Tiers()
+	}
+
+	t.Run("Tiers", func(t *testing.T) {
+		want := [][]string{{"cat", "car"}, {}, {"cot", "cop"}, {"cut"}}
+		if diff := cmp.Diff(want, words.Tiers()); diff != "" {
+			t.Errorf("Tiers: -want +got %s", diff)
+		}
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		want := [][]string{{"cat"}, {}, {"cot"}, {"cut"}}
+		if diff := cmp.Diff(want, tiersOf(words.Filter('t', 2))); diff != "" {
+			t.Errorf("Filter: -want +got %s", diff)
+		}
+	})
+
+	t.Run("RemoveWordOptions", func(t *testing.T) {
+		want := [][]string{{"car"}, {}, {"cop"}, {"cut"}}
+		if diff := cmp.Diff(want, tiersOf(words.RemoveWordOptions([]string{"cat", "cot"}))); diff != "" {
+			t.Errorf("RemoveWordOptions: -want +got %s", diff)
+		}
+	})
+
+	t.Run("MakeChoice", func(t *testing.T) {
+		c := words.MakeChoice()
+		if diff := cmp.Diff([][]string{{"cat", "car"}, {}, {}, {}}, tiersOf(c.Choice)); diff != "" {
+			t.Errorf("MakeChoice.Choice: -want +got %s", diff)
+		}
+		if diff := cmp.Diff([][]string{{}, {}, {"cot", "cop"}, {"cut"}}, tiersOf(c.Remaining)); diff != "" {
+			t.Errorf("MakeChoice.Remaining: -want +got %s", diff)
+		}
+	})
+}
+
 func TestDefinite(t *testing.T) {
 	line := ConcreteLine{Line: []rune("test"), Words: []string{"test"}}
 	definite := MakeDefinite(line)
@@ -1634,6 +1687,18 @@ func TestCompound(t *testing.T) {
 				t.Errorf("RemoveWordOption for non-existent word changed content. Got %v, want %v", collectLines(removedTHREE), collectLines(compoundForRemove))
 			}
 		})
+		t.Run("original unchanged", func(t *testing.T) {
+			original := MakeCompound([]PossibleLines{
+				MakeWordsFromPreferredAndObscure([]string{"one"}, []string{}, 3),
+				MakeWordsFromPreferredAndObscure([]string{"two", "six"}, []string{}, 3),
+				MakeWordsFromPreferredAndObscure([]string{"ten"}, []string{}, 3),
+			}, 3)
+			want := collectLines(original)
+			original.RemoveWordOptions([]string{"two"})
+			if diff := cmp.Diff(want, collectLines(original)); diff != "" {
+				t.Errorf("RemoveWordOption changed the original compound: -want +got %s", diff)
+			}
+		})
 	})
 
 	t.Run("FirstOrNull", func(t *testing.T) {
@@ -1723,3 +1788,183 @@ func collectLines(pl PossibleLines) []string {
 	}
 	return lines
 }
+
+func TestUnchecked(t *testing.T) {
+	unchecked := MakeUnchecked(2)
+
+	t.Run("Properties", func(t *testing.T) {
+		if unchecked.NumLetters() != 2 {
+			t.Errorf("Expected NumLetters 2, got %d", unchecked.NumLetters())
+		}
+		if unchecked.MaxPossibilities() != 26*26 {
+			t.Errorf("Expected MaxPossibilities %d, got %d", 26*26, unchecked.MaxPossibilities())
+		}
+		// The letters are left to crossing entries, so they need no choices.
+		if !unchecked.Decided() {
+			t.Error("Decided() should be true")
+		}
+		if unchecked.DefinitelyBlockedAt(0) {
+			t.Error("DefinitelyBlockedAt(0) should be false")
+		}
+		if unchecked.DefiniteWords() != nil {
+			t.Errorf("Expected DefiniteWords to be nil, got %v", unchecked.DefiniteWords())
+		}
+		if !isActuallyImpossible(MakeUnchecked(0)) {
+			t.Error("MakeUnchecked(0) should be Impossible")
+		}
+	})
+
+	t.Run("FilterAny", func(t *testing.T) {
+		cs := DefaultCharSet()
+		cs.Add('a')
+		cs.Add('b')
+		cs.Add(kBlocked)
+		filtered := unchecked.FilterAny(cs, 1)
+		got := DefaultCharSet()
+		filtered.CharsAt(got, 1)
+		if got.Count() != 2 || !got.Contains('a') || !got.Contains('b') {
+			t.Errorf("Expected CharsAt(1) to be a and b, got %v", got)
+		}
+		if filtered.FilterAny(cs, 1) != filtered {
+			t.Error("Filtering again by the same constraint should return the same line")
+		}
+
+		blocked := DefaultCharSet()
+		blocked.Add(kBlocked)
+		if !isActuallyImpossible(unchecked.FilterAny(blocked, 0)) {
+			t.Error("An unchecked run can't hold a block")
+		}
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		filtered := unchecked.Filter('x', 0).Filter('y', 1)
+		if diff := cmp.Diff(&ConcreteLine{Line: []rune("xy")}, filtered.FirstOrNull()); diff != "" {
+			t.Errorf("FirstOrNull mismatch (-want +got): %s", diff)
+		}
+		if !isActuallyImpossible(filtered.Filter('z', 0)) {
+			t.Error("Filtering by a removed letter should be Impossible")
+		}
+	})
+
+	t.Run("RemoveWordOptions", func(t *testing.T) {
+		if unchecked.RemoveWordOptions([]string{"ab"}) != unchecked {
+			t.Error("An unchecked run holds no words to remove")
+		}
+	})
+
+	t.Run("Iterate", func(t *testing.T) {
+		cs := DefaultCharSet()
+		cs.Add('a')
+		cs.Add('b')
+		narrowed := unchecked.FilterAny(cs, 0).FilterAny(cs, 1)
+		var got []string
+		for line := range narrowed.Iterate() {
+			if line.Words != nil {
+				t.Errorf("Expected no words in %q, got %v", string(line.Line), line.Words)
+			}
+			got = append(got, string(line.Line))
+		}
+		if diff := cmp.Diff([]string{"aa", "ab", "ba", "bb"}, got); diff != "" {
+			t.Errorf("Iterate mismatch (-want +got): %s", diff)
+		}
+	})
+
+	t.Run("MakeChoice", func(t *testing.T) {
+		choice := unchecked.MakeChoice()
+		first, second := DefaultCharSet(), DefaultCharSet()
+		choice.Choice.CharsAt(first, 0)
+		choice.Remaining.CharsAt(second, 0)
+		if first.Count() != 13 || second.Count() != 13 || first.Intersects(*second) {
+			t.Errorf("Expected the letters of the first cell to be split evenly, got %v and %v", first, second)
+		}
+	})
+	t.Run("NextToWords", func(t *testing.T) {
+		line := MakeBlockBetween(MakeWords([]string{"abc", "def"}, 2, 3), unchecked)
+		if line.MaxPossibilities() != 2*26*26 {
+			t.Errorf("Expected MaxPossibilities %d, got %d", 2*26*26, line.MaxPossibilities())
+		}
+		if line.Decided() {
+			t.Error("Decided() should be false while the words are undecided")
+		}
+		// The words are split, rather than the more numerous unchecked letters.
+		choice := line.MakeChoice()
+		for _, half := range []PossibleLines{choice.Choice, choice.Remaining} {
+			if !half.Decided() || half.MaxPossibilities() != 26*26 {
+				t.Errorf("Expected a single word next to the unchecked run, got %v", half)
+			}
+		}
+	})
+}
+
+func TestBarBetween(t *testing.T) {
+	first := MakeWords([]string{"ab", "cd"}, 2, 2)
+	second := MakeWords([]string{"efg", "hij"}, 2, 3)
+	bar := MakeBarBetween(first, second)
+
+	t.Run("Properties", func(t *testing.T) {
+		if bar.NumLetters() != 5 {
+			t.Errorf("Expected NumLetters 5, got %d", bar.NumLetters())
+		}
+		if bar.MaxPossibilities() != 4 {
+			t.Errorf("Expected MaxPossibilities 4, got %d", bar.MaxPossibilities())
+		}
+		for i := range 5 {
+			if bar.DefinitelyBlockedAt(i) {
+				t.Errorf("DefinitelyBlockedAt(%d) should be false", i)
+			}
+		}
+		if !isActuallyImpossible(MakeBarBetween(first, MakeImpossible(3))) {
+			t.Error("Expected a bar next to an impossible part to be Impossible")
+		}
+	})
+
+	t.Run("CharsAt", func(t *testing.T) {
+		cs := DefaultCharSet()
+		bar.CharsAt(cs, 2)
+		if cs.Count() != 2 || !cs.Contains('e') || !cs.Contains('h') {
+			t.Errorf("Expected CharsAt(2) to be e and h, got %v", cs)
+		}
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		filtered := bar.Filter('c', 0).Filter('j', 4)
+		want := &ConcreteLine{Line: []rune("cdhij"), Words: []string{"cd", "hij"}, Bars: []int{2}}
+		if diff := cmp.Diff(want, filtered.FirstOrNull()); diff != "" {
+			t.Errorf("FirstOrNull mismatch (-want +got): %s", diff)
+		}
+		if bar.Filter('a', 0).Filter('a', 0) == bar {
+			t.Error("Expected filtering to narrow the line")
+		}
+		if !isActuallyImpossible(bar.Filter(kBlocked, 2)) {
+			t.Error("Expected a block to be Impossible")
+		}
+	})
+
+	t.Run("Iterate", func(t *testing.T) {
+		var got []string
+		for line := range bar.Iterate() {
+			got = append(got, string(line.Line))
+			if diff := cmp.Diff([]int{2}, line.Bars); diff != "" {
+				t.Errorf("Bars mismatch (-want +got): %s", diff)
+			}
+		}
+		if diff := cmp.Diff([]string{"abefg", "abhij", "cdefg", "cdhij"}, got); diff != "" {
+			t.Errorf("Iterate mismatch (-want +got): %s", diff)
+		}
+	})
+
+	t.Run("Nested", func(t *testing.T) {
+		nested := MakeBlockBefore(MakeBarBetween(first, MakeBarBetween(first, first)))
+		want := []int{3, 5}
+		if diff := cmp.Diff(want, nested.FirstOrNull().Bars); diff != "" {
+			t.Errorf("Bars mismatch (-want +got): %s", diff)
+		}
+	})
+
+	t.Run("MakeChoice", func(t *testing.T) {
+		choice := bar.MakeChoice()
+		if got := choice.Choice.MaxPossibilities() + choice.Remaining.MaxPossibilities(); got != 4 {
+			t.Errorf("Expected the choice to split 4 possibilities, got %d", got)
+		}
+	})
+}
@@ -0,0 +1,217 @@
+// Package wordlist loads word and phrase lists into the normalized form used by the generator.
+//
+// Lists are often written for humans ("ice cream", "O'Hare", "Café"). Rather than rejecting such
+// lines, the loader folds them into lowercase a-z entries and keeps the original text around for
+// display (e.g. when exporting clues). The generator and xwcli only use the normalized words, so the
+// original text is only available to callers of this package.
+package wordlist
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Entry is a single normalized word along with the text it was loaded from.
+type Entry struct {
+	// Word is the normalized form of the entry, consisting only of the letters 'a' to 'z'.
+	Word string
+	// Display is the original (trimmed) text of the entry, e.g. "Ice cream" for "icecream". Nothing
+	// in xwgen reads it; it is kept for callers that map a grid's words back to their lines.
+	Display string
+}
+
+// WarningReason describes why a line was skipped during loading.
+type WarningReason int
+
+const (
+	// WarningUnsupportedCharacter means the line contained a character that cannot be folded
+	// into 'a' to 'z' and is not punctuation (e.g. a digit or a non-Latin letter).
+	WarningUnsupportedCharacter WarningReason = iota
+	// WarningDuplicate means the line normalized to a word that was already loaded.
+	WarningDuplicate
+	// WarningNoLetters means the line normalized to an empty word, e.g. because it was only
+	// punctuation.
+	WarningNoLetters
+)
+
+func (r WarningReason) String() string {
+	switch r {
+	case WarningUnsupportedCharacter:
+		return "unsupported character"
+	case WarningDuplicate:
+		return "duplicate"
+	case WarningNoLetters:
+		return "no letters"
+	default:
+		return fmt.Sprintf("WarningReason(%d)", int(r))
+	}
+}
+
+// Warning describes a single line that was skipped while loading a list.
+type Warning struct {
+	Line   int // 1-based line number.
+	Text   string
+	Reason WarningReason
+	Detail string
+}
+
+func (w Warning) String() string {
+	if w.Detail == "" {
+		return fmt.Sprintf("line %d: %s: %q", w.Line, w.Reason, w.Text)
+	}
+	return fmt.Sprintf("line %d: %s (%s): %q", w.Line, w.Reason, w.Detail, w.Text)
+}
+
+// Options configures Load.
+type Options struct {
+	// MinLength and MaxLength bound the length of normalized words. Entries outside of the bounds
+	// are skipped silently. Zero means no bound.
+	MinLength int
+	MaxLength int
+}
+
+// LoadFile loads a list from the file at path. See Load.
+func LoadFile(ctx context.Context, path string, opts Options) ([]Entry, []Warning, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	return Load(ctx, f, opts)
+}
+
+// Load reads one entry per line from r.
+//
+// Blank lines and lines starting with '#' are ignored. Every other line is normalized (see
+// Normalize); lines that cannot be normalized, that have no letters, or that duplicate an earlier
+// entry, are reported as warnings rather than failing the whole load. An error is only returned if reading fails or
+// ctx is done.
+func Load(ctx context.Context, r io.Reader, opts Options) ([]Entry, []Warning, error) {
+	var entries []Entry
+	var warnings []Warning
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		word, bad := Normalize(text)
+		if bad != 0 {
+			warnings = append(warnings, Warning{
+				Line:   lineNo,
+				Text:   text,
+				Reason: WarningUnsupportedCharacter,
+				Detail: fmt.Sprintf("%q", bad),
+			})
+			continue
+		}
+		if word == "" {
+			warnings = append(warnings, Warning{Line: lineNo, Text: text, Reason: WarningNoLetters})
+			continue
+		}
+		if opts.MinLength > 0 && len(word) < opts.MinLength {
+			continue
+		}
+		if opts.MaxLength > 0 && len(word) > opts.MaxLength {
+			continue
+		}
+		if seen[word] {
+			warnings = append(warnings, Warning{Line: lineNo, Text: text, Reason: WarningDuplicate, Detail: word})
+			continue
+		}
+		seen[word] = true
+		entries = append(entries, Entry{Word: word, Display: text})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return entries, warnings, nil
+}
+
+// Words returns the normalized words of the given entries, in order.
+func Words(entries []Entry) []string {
+	words := make([]string, len(entries))
+	for i, e := range entries {
+		words[i] = e.Word
+	}
+	return words
+}
+
+// Normalize folds s into lowercase 'a' to 'z'.
+//
+// Case and accents are folded ("Café" becomes "cafe"), ligatures are expanded ("Æ" becomes "ae"),
+// and whitespace and punctuation are stripped ("rock 'n' roll" becomes "rocknroll"). If s contains
+// a character that cannot be folded, Normalize returns that character as bad and the returned word
+// should not be used.
+func Normalize(s string) (word string, bad rune) {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if r >= 'a' && r <= 'z' {
+			b.WriteRune(r)
+			continue
+		}
+		if r >= 'A' && r <= 'Z' {
+			b.WriteRune(r - 'A' + 'a')
+			continue
+		}
+		if folded, ok := foldings[unicode.ToLower(r)]; ok {
+			b.WriteString(folded)
+			continue
+		}
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		return "", r
+	}
+	return b.String(), 0
+}
+
+// foldings maps lowercase accented Latin letters and ligatures to their unaccented form.
+var foldings = func() map[rune]string {
+	m := make(map[rune]string)
+	for folded, runes := range map[string]string{
+		"a":  "àáâãäåāăąǎ",
+		"c":  "çćĉċč",
+		"d":  "ďđð",
+		"e":  "èéêëēĕėęě",
+		"g":  "ĝğġģ",
+		"h":  "ĥħ",
+		"i":  "ìíîïĩīĭįı",
+		"j":  "ĵ",
+		"k":  "ķ",
+		"l":  "ĺļľŀł",
+		"n":  "ñńņňŉ",
+		"o":  "òóôõöøōŏőǒ",
+		"r":  "ŕŗř",
+		"s":  "śŝşšș",
+		"t":  "ţťŧț",
+		"u":  "ùúûüũūŭůűųǔ",
+		"w":  "ŵ",
+		"y":  "ýÿŷ",
+		"z":  "źżž",
+		"ae": "æ",
+		"oe": "œ",
+		"ss": "ß",
+		"th": "þ",
+	} {
+		for _, r := range runes {
+			m[r] = folded
+		}
+	}
+	return m
+}()
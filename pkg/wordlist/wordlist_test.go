@@ -0,0 +1,89 @@
+package wordlist
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNormalize(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    string
+		wantBad rune
+	}{
+		{in: "apple", want: "apple"},
+		{in: "Apple", want: "apple"},
+		{in: "ice cream", want: "icecream"},
+		{in: "O'Hare", want: "ohare"},
+		{in: "rock ’n’ roll", want: "rocknroll"},
+		{in: "X-RAY", want: "xray"},
+		{in: "Café", want: "cafe"},
+		{in: "Cafe\u0301", want: "cafe"},
+		{in: "Ærø", want: "aero"},
+		{in: "Straße", want: "strasse"},
+		{in: "R2D2", wantBad: '2'},
+		{in: "Москва", wantBad: 'М'},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			got, bad := Normalize(tc.in)
+			if bad != tc.wantBad {
+				t.Fatalf("Normalize(%q) bad = %q, want %q", tc.in, bad, tc.wantBad)
+			}
+			if got != tc.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	input := strings.Join([]string{
+		"# comment",
+		"Ice Cream",
+		"",
+		"R2D2",
+		"icecream",
+		"ok",
+		"Café",
+		"extraordinarily",
+		"---",
+	}, "\n")
+
+	entries, warnings, err := Load(context.Background(), strings.NewReader(input), Options{MinLength: 3, MaxLength: 10})
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	wantEntries := []Entry{
+		{Word: "icecream", Display: "Ice Cream"},
+		{Word: "cafe", Display: "Café"},
+	}
+	if diff := cmp.Diff(wantEntries, entries); diff != "" {
+		t.Errorf("Load entries mismatch (-want +got):\n%s", diff)
+	}
+
+	wantWarnings := []Warning{
+		{Line: 4, Text: "R2D2", Reason: WarningUnsupportedCharacter, Detail: "'2'"},
+		{Line: 5, Text: "icecream", Reason: WarningDuplicate, Detail: "icecream"},
+		{Line: 9, Text: "---", Reason: WarningNoLetters},
+	}
+	if diff := cmp.Diff(wantWarnings, warnings); diff != "" {
+		t.Errorf("Load warnings mismatch (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff([]string{"icecream", "cafe"}, Words(entries)); diff != "" {
+		t.Errorf("Words mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoad_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, _, err := Load(ctx, strings.NewReader("apple\n"), Options{}); err == nil {
+		t.Error("expected Load to fail with a canceled context")
+	}
+}
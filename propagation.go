@@ -0,0 +1,284 @@
+package xwgen
+
+import (
+	"context"
+	"slices"
+
+	"github.com/Eyas/xwgen/pkg/primitives"
+)
+
+// PropagationStats counts the work done narrowing lines down to those that agree with their
+// crossing lines, as part of SearchStats.
+type PropagationStats struct {
+	// Propagations is the number of times propagation ran (once per search node).
+	Propagations int64
+	// Revisions is the number of times a line was re-filtered against its crossing lines.
+	Revisions int64
+	// Narrowings is the number of revisions that removed at least one possibility.
+	Narrowings int64
+	// Removed is the total drop in MaxPossibilities across all narrowings.
+	Removed int64
+	// Wipeouts is the number of propagations that left some line with no possibilities.
+	Wipeouts int64
+}
+
+// propagate narrows every line in s to those that agree with their crossing lines. It returns false
+// if some line is left with no possibilities, along with the decision levels that explain why.
+func propagate(ctx context.Context, s *gridState) (gridState, levelSet, bool) {
+	return propagateChanged(ctx, s, nil)
+}
+
+// propagateChanged is like propagate, for a state that was already propagated before the given
+// lines were narrowed down. Only the lines crossing them are revisited, unless changed is nil.
+func propagateChanged(ctx context.Context, s *gridState, changed []lineRef) (gridState, levelSet, bool) {
+	var result gridState
+	var conflict levelSet
+	var ok bool
+	if s.config.fixedPassPropagation {
+		result, conflict, ok = propagateFixedPasses(ctx, s)
+	} else {
+		result, conflict, ok = propagateFrom(ctx, s, changed)
+	}
+
+	stats := s.config.propagationStats
+	stats.Propagations++
+	if !ok {
+		stats.Wipeouts++
+	}
+	return result, conflict, ok
+}
+
+// lineRef identifies a line of a gridState.
+type lineRef struct {
+	dir Direction
+	idx int
+}
+
+// propagateToFixpoint runs AC-3 style propagation: a worklist holds every line that may be
+// inconsistent with its crossing lines, and filtering a line only re-enqueues the crossing lines at
+// cells where its set of possible characters actually changed.
+//
+// Whenever a crossing line narrows a line, the line inherits the crossing line's reasons.
+func propagateToFixpoint(ctx context.Context, s *gridState) (gridState, levelSet, bool) {
+	return propagateFrom(ctx, s, nil)
+}
+
+// propagateFrom runs propagateToFixpoint with only the lines crossing the changed lines on the
+// initial worklist, or every line if changed is nil.
+func propagateFrom(ctx context.Context, s *gridState, changed []lineRef) (gridState, levelSet, bool) {
+	stats := s.config.propagationStats
+	result := s.withLines(slices.Clone(s.down), slices.Clone(s.across))
+	result.acrossReasons = slices.Clone(s.acrossReasons)
+	result.downReasons = slices.Clone(s.downReasons)
+	if conflict, ok := result.impossibleLines(); ok {
+		return result, conflict, false
+	}
+
+	// chars[dir][i][j] caches the characters that line i in direction dir can have at index j.
+	lines := [2][]primitives.PossibleLines{result.across, result.down}
+	reasons := [2][]levelSet{result.acrossReasons, result.downReasons}
+	var chars [2][][]primitives.CharSet
+	for dir := range lines {
+		chars[dir] = make([][]primitives.CharSet, len(lines[dir]))
+		for i, line := range lines[dir] {
+			chars[dir][i] = charsOf(line)
+		}
+	}
+
+	var queue []lineRef
+	var queued [2][]bool
+	enqueue := func(ref lineRef) {
+		if !queued[ref.dir][ref.idx] {
+			queue = append(queue, ref)
+			queued[ref.dir][ref.idx] = true
+		}
+	}
+	for dir := range lines {
+		queued[dir] = make([]bool, len(lines[dir]))
+	}
+	if changed == nil {
+		for dir := range lines {
+			for i := range lines[dir] {
+				enqueue(lineRef{dir: Direction(dir), idx: i})
+			}
+		}
+	}
+	for _, ref := range changed {
+		other := 1 - int(ref.dir)
+		for j := range lines[other] {
+			enqueue(lineRef{dir: Direction(other), idx: j})
+		}
+	}
+
+	for len(queue) > 0 {
+		if ctx.Err() != nil {
+			return result, levelSet{}, false
+		}
+		ref := queue[0]
+		queue = queue[1:]
+		queued[ref.dir][ref.idx] = false
+
+		dir, other := int(ref.dir), 1-int(ref.dir)
+		line := lines[dir][ref.idx]
+		stats.Revisions++
+
+		filtered := line
+		lineReasons := reasons[dir][ref.idx]
+		for j := range line.NumLetters() {
+			// The crossing line at index j is line j of the other direction, and this line is at its
+			// index ref.idx.
+			constraint := chars[other][j][ref.idx]
+			if constraint.IsFull() {
+				continue
+			}
+			if f := filtered.FilterAny(&constraint, j); f != filtered {
+				filtered = f
+				lineReasons = lineReasons.union(reasons[other][j])
+			}
+		}
+		if filtered == line {
+			continue
+		}
+
+		stats.Narrowings++
+		stats.Removed += line.MaxPossibilities() - filtered.MaxPossibilities()
+		lines[dir][ref.idx] = filtered
+		reasons[dir][ref.idx] = lineReasons
+		if impossible(filtered) {
+			return result, lineReasons, false
+		}
+
+		newChars := charsOf(filtered)
+		for j := range newChars {
+			if newChars[j] == chars[dir][ref.idx][j] {
+				continue
+			}
+			enqueue(lineRef{dir: Direction(other), idx: j})
+		}
+		chars[dir][ref.idx] = newChars
+	}
+
+	return result, levelSet{}, true
+}
+
+// charsOf returns, for each index in line, the characters that line can have there.
+func charsOf(line primitives.PossibleLines) []primitives.CharSet {
+	chars := make([]primitives.CharSet, line.NumLetters())
+	for j := range chars {
+		line.CharsAt(&chars[j], j)
+	}
+	return chars
+}
+
+// propagateFixedPasses runs a fixed number of alternating prefilter passes over all lines.
+//
+// This is the original propagation scheme, kept for comparison against propagateToFixpoint. It does
+// not track which crossing lines narrowed a line, so every narrowed line is blamed on every decision
+// made so far.
+func propagateFixedPasses(ctx context.Context, root *gridState) (gridState, levelSet, bool) {
+	everything := levelsUpTo(root.depth())
+	// prefilter narrows lines in place, so work on a copy.
+	original := root
+	cloned := root.withLines(slices.Clone(root.down), slices.Clone(root.across))
+	root = &cloned
+	direction := DirectionHorizontal
+	for try := range 4 {
+		newState, changed := prefilter(ctx, *root, direction)
+		if !changed && try > 1 {
+			break
+		}
+
+		root = &newState
+		if direction == DirectionVertical {
+			direction = DirectionHorizontal
+		} else {
+			direction = DirectionVertical
+		}
+	}
+	result := *root
+	result.acrossReasons = slices.Clone(original.acrossReasons)
+	result.downReasons = slices.Clone(original.downReasons)
+	for i := range result.across {
+		if result.across[i] != original.across[i] {
+			result.acrossReasons[i] = everything
+		}
+	}
+	for i := range result.down {
+		if result.down[i] != original.down[i] {
+			result.downReasons[i] = everything
+		}
+	}
+	if slices.ContainsFunc(result.down, impossible) || slices.ContainsFunc(result.across, impossible) {
+		return result, everything, false
+	}
+	return result, levelSet{}, true
+}
+
+func prefilter(ctx context.Context, s gridState, dir Direction) (gridState, bool) {
+	if slices.ContainsFunc(s.down, impossible) || slices.ContainsFunc(s.across, impossible) {
+		return s, false
+	}
+	if ctx.Err() != nil {
+		return s, false
+	}
+
+	var toFilter, constraint []primitives.PossibleLines
+	if dir == DirectionHorizontal {
+		toFilter = s.across
+		constraint = s.down
+	} else {
+		toFilter = s.down
+		constraint = s.across
+	}
+
+	// i and j here are abstracted wlog based on toFilter/constraint, not truly
+	// connected to Horizontal vs Vertical.
+	//
+	// available[i][j] is the set of characters that can be placed at (x, y) in the grid.
+	available := make([][]primitives.CharSet, len(constraint))
+	for i, constraintLine := range constraint {
+		available[i] = make([]primitives.CharSet, constraintLine.NumLetters())
+
+		for j := range constraintLine.NumLetters() {
+			available[i][j] = *primitives.DefaultCharSet()
+			constraintLine.CharsAt(&available[i][j], j)
+		}
+	}
+
+	anyChanged := false
+	for j := range toFilter {
+		tf := toFilter[j]
+
+		// if all characters in available[i] are full, then the line cannot be filtered
+		// any further.
+		allFull := true
+		for i := range tf.NumLetters() {
+			if !available[i][j].IsFull() {
+				allFull = false
+				break
+			}
+		}
+		if allFull {
+			continue
+		}
+
+		newTf := tf
+		for i := range tf.NumLetters() {
+			newTf = newTf.FilterAny(&available[i][j], i)
+		}
+		stats := s.config.propagationStats
+		stats.Revisions++
+		if newTf != tf {
+			anyChanged = true
+			toFilter[j] = newTf
+			stats.Narrowings++
+			stats.Removed += tf.MaxPossibilities() - newTf.MaxPossibilities()
+		}
+	}
+
+	if dir == DirectionHorizontal {
+		return s.withLines(constraint, toFilter), anyChanged
+	} else {
+		return s.withLines(toFilter, constraint), anyChanged
+	}
+}
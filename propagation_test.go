@@ -0,0 +1,70 @@
+package xwgen
+
+import (
+	"context"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/Eyas/xwgen/pkg/primitives"
+)
+
+func TestPropagateToFixpoint(t *testing.T) {
+	words := primitives.MakeWordsFromTiers([][]string{{"bad", "ace", "dee", "bed", "cab", "zoo"}}, 3)
+	state := gridState{
+		down:          []primitives.PossibleLines{words, words, words},
+		across:        []primitives.PossibleLines{words.Filter('b', 0), words, words},
+		downReasons:   make([]levelSet, 3),
+		acrossReasons: make([]levelSet, 3),
+		rand:          rand.New(rand.NewPCG(1, 2)),
+		config:        &searchConfig{propagationStats: &PropagationStats{}},
+	}
+
+	result, _, ok := propagateToFixpoint(context.Background(), &state)
+	if !ok {
+		t.Fatal("expected propagation to succeed")
+	}
+
+	// Every character a line can have must be allowed by the crossing line.
+	for y, across := range result.across {
+		for x := range across.NumLetters() {
+			var a, d primitives.CharSet
+			across.CharsAt(&a, x)
+			result.down[x].CharsAt(&d, y)
+			if a != d {
+				t.Errorf("cell (%d, %d): across allows %s, down allows %s", x, y, a.String(), d.String())
+			}
+		}
+	}
+
+	// The input state must be left untouched.
+	if state.across[1] != words {
+		t.Error("propagateToFixpoint modified its input")
+	}
+
+	stats := state.config.propagationStats
+	if stats.Revisions == 0 || stats.Narrowings == 0 {
+		t.Errorf("expected revisions and narrowings to be counted, got %+v", *stats)
+	}
+}
+
+func TestPropagateToFixpoint_Wipeout(t *testing.T) {
+	words := primitives.MakeWordsFromTiers([][]string{{"abc", "bad"}}, 3)
+	state := gridState{
+		// The top-left cell must be 'a' for the first column and 'b' for the first row.
+		down:   []primitives.PossibleLines{words.Filter('a', 0), words, words},
+		across: []primitives.PossibleLines{words.Filter('b', 0), words, words},
+		// The first column and row were narrowed by decisions 0 and 2.
+		downReasons:   []levelSet{levelSet{}.with(0), {}, {}},
+		acrossReasons: []levelSet{levelSet{}.with(2), {}, {}},
+		path:          make([]decision, 3),
+		config:        &searchConfig{propagationStats: &PropagationStats{}},
+	}
+
+	_, conflict, ok := propagateToFixpoint(context.Background(), &state)
+	if ok {
+		t.Fatal("expected propagation to detect a wipeout")
+	}
+	if got, want := conflict.String(), "[0 2]"; got != want {
+		t.Errorf("expected the wipeout to be blamed on decisions %s, got %s", want, got)
+	}
+}
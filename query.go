@@ -0,0 +1,188 @@
+package xwgen
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/Eyas/xwgen/internal"
+	"github.com/Eyas/xwgen/pkg/primitives"
+)
+
+// Query looks words up in the generator's word list.
+type Query struct {
+	// Pattern is a word pattern, e.g. "a?c?e": letters must match, '?' or '.' is any letter,
+	// "[abc]" is any of the listed letters and '*' is any run of letters, possibly empty. Only one
+	// '*' is allowed. An empty pattern matches every word.
+	Pattern string
+	// Length restricts the results to words of this length. Zero means any length.
+	Length int
+	// Contains restricts the results to words containing every one of these substrings.
+	Contains []string
+	// Limit caps the number of results. Zero means no limit.
+	Limit int
+}
+
+// QueryResult is a word matched by a Query.
+type QueryResult struct {
+	Word string
+	// Score and Tier are the weight and index of the word's tier.
+	Score float64
+	Tier  int
+}
+
+// queryElement is a single element of a parsed query pattern: a set of letters for one position,
+// or a '*' wildcard.
+type queryElement struct {
+	letters primitives.CharSet
+	star    bool
+}
+
+// parseQueryPattern parses a Query.Pattern.
+func parseQueryPattern(pattern string) ([]queryElement, error) {
+	if pattern == "" {
+		return []queryElement{{star: true}}, nil
+	}
+	anyLetter := allowedChars(OpenCell)
+
+	var elements []queryElement
+	stars := 0
+	runes := []rune(strings.ToLower(pattern))
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '*':
+			stars++
+			if stars > 1 {
+				return nil, fmt.Errorf("pattern %q has more than one '*'", pattern)
+			}
+			elements = append(elements, queryElement{star: true})
+		case r == '?' || r == '.':
+			elements = append(elements, queryElement{letters: *anyLetter})
+		case r == '[':
+			end := slices.Index(runes[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("pattern %q has an unterminated '['", pattern)
+			}
+			var letters primitives.CharSet
+			for _, l := range runes[i+1 : i+end] {
+				if l < 'a' || l > 'z' {
+					return nil, fmt.Errorf("pattern %q: unexpected %q in brackets", pattern, l)
+				}
+				letters.Add(l)
+			}
+			elements = append(elements, queryElement{letters: letters})
+			i += end
+		case r >= 'a' && r <= 'z':
+			var letters primitives.CharSet
+			letters.Add(r)
+			elements = append(elements, queryElement{letters: letters})
+		default:
+			return nil, fmt.Errorf("pattern %q: unexpected %q", pattern, r)
+		}
+	}
+	return elements, nil
+}
+
+// Query returns the words matching q, in the order of their tiers: best scores first, then earlier
+// tiers, then shorter words, then the order of the word list.
+func (g *Generator) Query(ctx context.Context, q Query) ([]QueryResult, error) {
+	elements, err := parseQueryPattern(q.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	star := slices.IndexFunc(elements, func(e queryElement) bool { return e.star })
+	fixed := len(elements)
+	if star >= 0 {
+		fixed--
+	}
+
+	byLength := g.queryWords()
+	var results []QueryResult
+	for _, length := range slices.Sorted(maps.Keys(byLength)) {
+		words := byLength[length]
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if (q.Length > 0 && length != q.Length) || length < fixed || (star < 0 && length != fixed) {
+			continue
+		}
+
+		// Expand the wildcard to fill the length, then narrow the words down position by position.
+		positions := elements
+		if star >= 0 {
+			expanded := slices.Clone(elements[:star])
+			for range length - fixed {
+				expanded = append(expanded, queryElement{letters: *allowedChars(OpenCell)})
+			}
+			positions = append(expanded, elements[star+1:]...)
+		}
+		for i, e := range positions {
+			words = words.FilterAny(&e.letters, i)
+		}
+
+		for line := range words.Iterate() {
+			word := string(line.Line)
+			if !containsAll(word, q.Contains) {
+				continue
+			}
+			tier, _ := g.Tier(word)
+			results = append(results, QueryResult{Word: word, Score: g.Tiers[tier].Weight, Tier: tier})
+		}
+	}
+
+	slices.SortStableFunc(results, func(a, b QueryResult) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Tier, b.Tier)
+	})
+	if q.Limit > 0 && len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results, nil
+}
+
+func containsAll(word string, substrings []string) bool {
+	for _, s := range substrings {
+		if !strings.Contains(word, strings.ToLower(s)) {
+			return false
+		}
+	}
+	return true
+}
+
+// queryWords returns the words of the word list that aren't excluded, by length, keeping the order
+// of the tiers.
+func (g *Generator) queryWords() map[int]primitives.PossibleLines {
+	if g.lazyQueryWords != nil {
+		return g.lazyQueryWords
+	}
+
+	excluded := make(map[string]bool)
+	for _, word := range g.ExcludedWords {
+		excluded[word] = true
+	}
+	tiers := g.wordTiers()
+	byLength := make(map[int][][]string)
+	for t, tier := range g.Tiers {
+		for _, word := range tier.Words {
+			if tiers[word] != t || excluded[word] || internal.IsExcluded(word, g.ExcludedPatterns, g.ExcludedSubstrings) {
+				continue
+			}
+			if _, ok := byLength[len(word)]; !ok {
+				byLength[len(word)] = make([][]string, len(g.Tiers))
+			}
+			byLength[len(word)][t] = append(byLength[len(word)][t], word)
+		}
+	}
+
+	g.lazyQueryWords = make(map[int]primitives.PossibleLines)
+	for length, words := range byLength {
+		g.lazyQueryWords[length] = primitives.MakeWordsFromTiers(words, length)
+	}
+	return g.lazyQueryWords
+}
@@ -0,0 +1,60 @@
+package xwgen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestQuery(t *testing.T) {
+	gen := CreateGenerator(5, PreferredAndObscureTiers(
+		[]string{"apple", "ample", "angle", "quiet", "squat", "abide", "ale", "pneumonoultramicroscopicsilicovolcanoconiosis"},
+		[]string{"aisle", "quota", "apple"},
+	), []string{"abide"}, nil, GeneratorParams{})
+
+	tests := []struct {
+		name    string
+		query   Query
+		want    []string
+		wantErr bool
+	}{
+		{name: "pattern", query: Query{Pattern: "A??LE"}, want: []string{"apple", "ample", "angle", "aisle"}},
+		{name: "letter sets", query: Query{Pattern: "a[mn]?le"}, want: []string{"ample", "angle"}},
+		{name: "crossing letters", query: Query{Pattern: "?u?[ae]?"}, want: []string{"quiet"}},
+		{name: "wildcard", query: Query{Pattern: "a*e"}, want: []string{"ale", "apple", "ample", "angle", "aisle"}},
+		{name: "length and contains", query: Query{Length: 5, Contains: []string{"q"}}, want: []string{"quiet", "squat", "quota"}},
+		{name: "excluded", query: Query{Pattern: "ab*"}, want: nil},
+		{name: "long entry", query: Query{Pattern: "p*s"}, want: []string{"pneumonoultramicroscopicsilicovolcanoconiosis"}},
+		{name: "limit", query: Query{Pattern: "a*", Limit: 2}, want: []string{"ale", "apple"}},
+		{name: "two wildcards", query: Query{Pattern: "a**"}, wantErr: true},
+		{name: "unterminated", query: Query{Pattern: "a[bc"}, wantErr: true},
+		{name: "bad character", query: Query{Pattern: "a1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := gen.Query(context.Background(), tt.query)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", results)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Query returned error: %v", err)
+			}
+			var got []string
+			for _, r := range results {
+				got = append(got, r.Word)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Query(%+v) mismatch (-want +got):\n%s", tt.query, diff)
+			}
+		})
+	}
+
+	results, err := gen.Query(context.Background(), Query{Pattern: "aisle"})
+	if err != nil || len(results) != 1 || results[0].Tier != 1 || results[0].Score != 0.5 {
+		t.Errorf("expected aisle to be scored as obscure, got %+v, %v", results, err)
+	}
+}
@@ -0,0 +1,95 @@
+package xwgen
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strings"
+
+	"github.com/Eyas/xwgen/pkg/primitives"
+)
+
+// Point is the position of a cell, at column X and row Y.
+type Point struct {
+	X, Y int
+}
+
+// Rect returns the points of the rectangle from (x0, y0) to (x1, y1), inclusive.
+func Rect(x0, y0, x1, y1 int) []Point {
+	var points []Point
+	for y := min(y0, y1); y <= max(y0, y1); y++ {
+		for x := min(x0, x1); x <= max(x0, x1); x++ {
+			points = append(points, Point{X: x, Y: y})
+		}
+	}
+	return points
+}
+
+// Refill returns the grids that agree with grid outside of region, refilling the letters inside it.
+// Blocks inside the region are kept; make them AnyCell in grid to let the refill move them. Rows and
+// columns that don't cross the region are kept as they are, even if their entries aren't words.
+func (g *Generator) Refill(ctx context.Context, grid Grid, region []Point) (iter.Seq[Grid], error) {
+	if err := checkPattern(grid, g.LineLength); err != nil {
+		return nil, err
+	}
+	cells := cloneCells(grid.grid)
+	crossedRows := make([]bool, grid.Height())
+	crossedCols := make([]bool, grid.Width())
+	for _, p := range region {
+		if p.X < 0 || p.Y < 0 || p.X >= grid.Width() || p.Y >= grid.Height() {
+			return nil, fmt.Errorf("cell (%d, %d) is outside of the grid", p.X, p.Y)
+		}
+		if v := cells[p.Y][p.X]; v >= 'a' && v <= 'z' {
+			cells[p.Y][p.X] = OpenCell
+		}
+		crossedRows[p.Y] = true
+		crossedCols[p.X] = true
+	}
+	pattern := NewGrid(cells)
+	original := grid.Repr()
+
+	seq := g.possibleGrids(ctx, fmt.Sprintf("refill %s %v", original, region), func(s *gridState) {
+		for y := range s.across {
+			if line, ok := fixedLine(cells[y]); ok && !crossedRows[y] {
+				s.across[y] = line
+			}
+		}
+		for x := range s.down {
+			column := make([]rune, len(cells))
+			for y := range cells {
+				column[y] = cells[y][x]
+			}
+			if line, ok := fixedLine(column); ok && !crossedCols[x] {
+				s.down[x] = line
+			}
+		}
+		s.applyCells(patternCells(pattern))
+	}, false)
+	return func(yield func(Grid) bool) {
+		for grid := range seq {
+			if grid.Repr() == original {
+				continue
+			}
+			if !yield(grid) {
+				return
+			}
+		}
+	}, nil
+}
+
+// fixedLine returns a line holding exactly the given cells, and false if some of them aren't letters
+// or blocks.
+func fixedLine(cells []rune) (primitives.PossibleLines, bool) {
+	for _, c := range cells {
+		if c != BlockCell && (c < 'a' || c > 'z') {
+			return nil, false
+		}
+	}
+	var words []string
+	for _, run := range strings.FieldsFunc(string(cells), func(r rune) bool { return r == BlockCell }) {
+		if len(run) >= 2 {
+			words = append(words, run)
+		}
+	}
+	return primitives.MakeDefinite(primitives.ConcreteLine{Line: cells, Words: words}), true
+}
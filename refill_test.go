@@ -0,0 +1,46 @@
+package xwgen
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestRefill(t *testing.T) {
+	words := loadWords(t)
+	gen := CreateGenerator(4, PreferredAndObscureTiers(words, nil), nil, rand.New(rand.NewPCG(1, 2)), GeneratorParams{MinWordLength: 3})
+	grid := mustParseGrid(t, "rsa`\nalba\nnull\n`rei")
+	region := Rect(2, 2, 3, 3)
+
+	grids, err := gen.Refill(context.Background(), grid, region)
+	if err != nil {
+		t.Fatalf("Refill returned error: %v", err)
+	}
+	count := 0
+	for refilled := range grids {
+		count++
+		if refilled.Repr() == grid.Repr() {
+			t.Error("expected the original grid not to be yielded")
+		}
+		for y := range 4 {
+			for x := range 4 {
+				if !slices.Contains(region, Point{X: x, Y: y}) && refilled.Get(x, y) != grid.Get(x, y) {
+					t.Errorf("expected (%d, %d) outside the region to be kept:\n%s", x, y, refilled.Repr())
+				}
+			}
+		}
+		entries := refilled.Words()
+		slices.Sort(entries)
+		if len(slices.Compact(entries)) != len(refilled.Words()) {
+			t.Errorf("expected no duplicate entries:\n%s", refilled.Repr())
+		}
+	}
+	if count == 0 {
+		t.Error("expected at least one refill")
+	}
+
+	if _, err := gen.Refill(context.Background(), grid, []Point{{X: 4, Y: 0}}); err == nil {
+		t.Error("expected an error for a region outside of the grid")
+	}
+}
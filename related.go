@@ -0,0 +1,191 @@
+package xwgen
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// WordRelation decides which words count as the same entry when checking a grid for duplicates,
+// e.g. "see" and "sees".
+type WordRelation interface {
+	// Root returns a key shared by every word related to word. Words with different roots are
+	// unrelated.
+	Root(word string) string
+}
+
+// ChainRelations returns a relation that applies each of the given relations in turn, so that
+// e.g. an equivalence class can be combined with a stemmer.
+func ChainRelations(relations ...WordRelation) WordRelation {
+	return chainedRelation(relations)
+}
+
+type chainedRelation []WordRelation
+
+func (c chainedRelation) Root(word string) string {
+	for _, r := range c {
+		word = r.Root(word)
+	}
+	return word
+}
+
+// EnglishStemmer relates English words that differ only by a common inflection, e.g. "act",
+// "acts", "acted" and "acting".
+//
+// It is a deliberately light stemmer: it only strips plural and verb endings, and never reduces a
+// word below three letters, so that short entries like "red" or "sing" keep their own identity.
+type EnglishStemmer struct{}
+
+const minStemLength = 3
+
+func (EnglishStemmer) Root(word string) string {
+	stem := word
+	switch {
+	case strings.HasSuffix(stem, "ies") && len(stem)-3 >= minStemLength-1:
+		stem = stem[:len(stem)-3] + "y"
+	case (strings.HasSuffix(stem, "sses") || strings.HasSuffix(stem, "shes") || strings.HasSuffix(stem, "ches") ||
+		strings.HasSuffix(stem, "xes") || strings.HasSuffix(stem, "zes")) && len(stem)-2 >= minStemLength:
+		// "sizes" and "caches" only add an 's' to "size" and "cache".
+		if silentE(stem[:len(stem)-2]) {
+			stem = stem[:len(stem)-1]
+		} else {
+			stem = stem[:len(stem)-2]
+		}
+	case strings.HasSuffix(stem, "s") && !strings.HasSuffix(stem, "ss") && len(stem)-1 >= minStemLength:
+		stem = stem[:len(stem)-1]
+	case strings.HasSuffix(stem, "eed"):
+		// "agreed" comes from "agree", but "speed" and "seed" aren't inflections.
+		if measure(stem[:len(stem)-3]) > 0 {
+			stem = stem[:len(stem)-1]
+		}
+	case strings.HasSuffix(stem, "ed") && len(stem)-2 >= minStemLength && hasVowel(stem[:len(stem)-2]):
+		stem = verbStem(stem[:len(stem)-2])
+	case strings.HasSuffix(stem, "ing") && len(stem)-3 >= minStemLength && hasVowel(stem[:len(stem)-3]):
+		stem = verbStem(stem[:len(stem)-3])
+	}
+	return stem
+}
+
+// verbStem returns the verb that stem is left of once "-ed" or "-ing" is stripped: "stopp" (from
+// "stopped") becomes "stop", and a short stem like "bak" (from "baked") gets back its silent 'e'.
+func verbStem(stem string) string {
+	if undoubled := undouble(stem); undoubled != stem {
+		return undoubled
+	}
+	if silentE(stem) {
+		return stem + "e"
+	}
+	return stem
+}
+
+// silentE returns true if stem looks like a word that lost a silent 'e' to an ending: a short stem
+// that ends like "bak" (from "baking") or "cach" (from "caches").
+func silentE(stem string) bool {
+	if measure(stem) != 1 {
+		return false
+	}
+	if n := len(stem); strings.HasSuffix(stem, "ch") {
+		return n >= 4 && isConsonant(stem, n-4) && !isConsonant(stem, n-3)
+	}
+	return endsShort(stem)
+}
+
+// isConsonant returns true if the i-th letter of word is a consonant. 'y' is a consonant unless it
+// follows one, as in "fly".
+func isConsonant(word string, i int) bool {
+	switch word[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !isConsonant(word, i-1)
+	}
+	return true
+}
+
+// hasVowel returns true if stem has a vowel, so that "string" isn't taken for "str" and "-ing".
+func hasVowel(stem string) bool {
+	for i := range len(stem) {
+		if !isConsonant(stem, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// measure returns the number of times a run of vowels is followed by a run of consonants in stem,
+// e.g. 0 for "sp", 1 for "bak" and 2 for "open".
+func measure(stem string) int {
+	m := 0
+	for i := 1; i < len(stem); i++ {
+		if isConsonant(stem, i) && !isConsonant(stem, i-1) {
+			m++
+		}
+	}
+	return m
+}
+
+// endsShort returns true if stem ends with a consonant, a vowel and a consonant other than 'w',
+// 'x' or 'y', as verbs that drop a silent 'e' do, e.g. "bak" (from "baking").
+func endsShort(stem string) bool {
+	n := len(stem)
+	return n >= 3 && isConsonant(stem, n-3) && !isConsonant(stem, n-2) && isConsonant(stem, n-1) &&
+		!strings.ContainsRune("wxy", rune(stem[n-1]))
+}
+
+// undouble turns a doubled final consonant into a single one, e.g. "stopp" (from "stopped") into
+// "stop".
+func undouble(stem string) string {
+	n := len(stem)
+	if n-1 < minStemLength || stem[n-1] != stem[n-2] || strings.ContainsRune("aeiouls", rune(stem[n-1])) {
+		return stem
+	}
+	return stem[:n-1]
+}
+
+// EquivalenceClasses relates words that were explicitly listed together, e.g. "go", "went" and
+// "gone". Words that are in no class are only related to themselves.
+type EquivalenceClasses map[string]string
+
+func (e EquivalenceClasses) Root(word string) string {
+	if root, ok := e[word]; ok {
+		return root
+	}
+	return word
+}
+
+// LoadEquivalenceClasses reads one class per line, as words separated by spaces or commas. Blank
+// lines and lines starting with '#' are ignored. If a word appears in more than one class, the
+// classes are merged.
+func LoadEquivalenceClasses(r io.Reader) (EquivalenceClasses, error) {
+	classes := make(EquivalenceClasses)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words := strings.FieldsFunc(strings.ToLower(line), func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+		if len(words) == 0 {
+			continue
+		}
+
+		root := classes.Root(words[0])
+		for _, word := range words[1:] {
+			if other := classes.Root(word); other != root {
+				// Merge the other class into this one.
+				for w, r := range classes {
+					if r == other {
+						classes[w] = root
+					}
+				}
+				classes[other] = root
+			}
+		}
+		for _, word := range words {
+			classes[word] = root
+		}
+	}
+	return classes, scanner.Err()
+}
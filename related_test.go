@@ -0,0 +1,104 @@
+package xwgen
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEnglishStemmer(t *testing.T) {
+	related := [][]string{
+		{"see", "sees"},
+		{"act", "acts", "acted", "acting"},
+		{"bake", "bakes", "baked", "baking"},
+		{"stop", "stops", "stopped", "stopping"},
+		{"fly", "flies"},
+		{"box", "boxes"},
+		{"axe", "axes"},
+		{"size", "sizes", "sized"},
+		{"cache", "caches", "cached"},
+		{"glass", "glasses"},
+		{"call", "called"},
+		{"hop", "hops", "hopped", "hopping"},
+		{"hope", "hopes", "hoped", "hoping"},
+		{"rate", "rated", "rating"},
+		{"plan", "planned", "planning"},
+		{"plane", "planed"},
+		{"string", "strings"},
+		{"speed", "speeds", "speeding"},
+		{"agree", "agreed"},
+		{"open", "opened"},
+		{"fly", "flying"},
+	}
+	unrelated := [][2]string{
+		{"red", "re"},
+		{"sing", "s"},
+		{"add", "ad"},
+		{"bus", "bu"},
+		{"see", "sea"},
+		{"rate", "rat"},
+		{"note", "not"},
+		{"plane", "plan"},
+		{"hope", "hop"},
+		{"hope", "hopping"},
+		{"string", "str"},
+		{"speed", "spe"},
+	}
+
+	var s EnglishStemmer
+	for _, class := range related {
+		for _, word := range class[1:] {
+			if s.Root(word) != s.Root(class[0]) {
+				t.Errorf("expected %q and %q to be related, got roots %q and %q", class[0], word, s.Root(class[0]), s.Root(word))
+			}
+		}
+	}
+	for _, pair := range unrelated {
+		if s.Root(pair[0]) == s.Root(pair[1]) {
+			t.Errorf("expected %q and %q to be unrelated, both have root %q", pair[0], pair[1], s.Root(pair[0]))
+		}
+	}
+}
+
+func TestLoadEquivalenceClasses(t *testing.T) {
+	classes, err := LoadEquivalenceClasses(strings.NewReader(strings.Join([]string{
+		"# irregular verbs",
+		"go, went, gone",
+		"",
+		"be is are",
+		"was were be",
+	}, "\n")))
+	if err != nil {
+		t.Fatalf("LoadEquivalenceClasses returned error: %v", err)
+	}
+
+	for _, group := range [][]string{{"go", "went", "gone"}, {"be", "is", "are", "was", "were"}} {
+		for _, word := range group {
+			if classes.Root(word) != classes.Root(group[0]) {
+				t.Errorf("expected %q and %q to be related", group[0], word)
+			}
+		}
+	}
+	if classes.Root("go") == classes.Root("be") {
+		t.Error("expected go and be to be unrelated")
+	}
+	if classes.Root("other") != "other" {
+		t.Errorf("expected unlisted words to be their own root, got %q", classes.Root("other"))
+	}
+
+	chained := ChainRelations(classes, EnglishStemmer{})
+	if chained.Root("went") != chained.Root("go") || chained.Root("acts") != chained.Root("act") {
+		t.Error("expected chained relation to apply both classes and stemming")
+	}
+}
+
+func TestPossibleGrids_RelatedWords(t *testing.T) {
+	// The words make four 4x4 grids, as two pairs of a grid and its transposition. One pair has both
+	// "tat" and "tats".
+	words := []string{"acid", "anal", "asl", "caro", "cat", "erie", "nana", "nsa", "race", "res", "sod", "stay", "tat", "tats", "tay", "tee"}
+	race := []string{joinRows("`cat", "race", "erie", "sod`"), joinRows("`res", "caro", "acid", "tee`")}
+	tats := []string{joinRows("`tat", "nana", "stay", "asl`"), joinRows("`nsa", "tats", "anal", "tay`")}
+
+	checkGrids(t, testGenerator(4, words, GeneratorParams{MinWordLength: 3}), "", slices.Concat(race, tats))
+	checkGrids(t, testGenerator(4, words, GeneratorParams{MinWordLength: 3, Related: EnglishStemmer{}}), "", race)
+}
@@ -0,0 +1,16 @@
+package xwgen
+
+// luby returns the i-th term (starting at 1) of the Luby sequence: 1, 1, 2, 1, 1, 2, 4, 1, 1, 2, ...
+//
+// Scaling restart budgets by this sequence is within a logarithmic factor of the best fixed budget,
+// without having to know that budget in advance.
+func luby(i int) int64 {
+	for k := 1; ; k++ {
+		if i == 1<<k-1 {
+			return 1 << (k - 1)
+		}
+		if i < 1<<k-1 {
+			return luby(i - (1 << (k - 1)) + 1)
+		}
+	}
+}
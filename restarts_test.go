@@ -0,0 +1,52 @@
+package xwgen
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestLuby(t *testing.T) {
+	var got []int64
+	for i := 1; i <= 15; i++ {
+		got = append(got, luby(i))
+	}
+	want := []int64{1, 1, 2, 1, 1, 2, 4, 1, 1, 2, 1, 1, 2, 4, 8}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestPossibleGrids_Restarts(t *testing.T) {
+	// A small word list keeps the repeated exhaustive searches fast.
+	var words []string
+	for i, word := range loadWords(t) {
+		if i%4 == 0 {
+			words = append(words, word)
+		}
+	}
+	grids := func(params GeneratorParams) ([]string, ConflictStats) {
+		params.MinWordLength = 3
+		gen := CreateGenerator(3, PreferredAndObscureTiers(words, nil), nil, rand.New(rand.NewPCG(1, 2)), params)
+		var reprs []string
+		for grid := range gen.PossibleGrids(context.Background()) {
+			reprs = append(reprs, grid.Repr())
+		}
+		slices.Sort(reprs)
+		return reprs, gen.SearchStats().Conflicts
+	}
+
+	want, _ := grids(GeneratorParams{})
+	got, stats := grids(GeneratorParams{RestartBase: 4})
+	if stats.Restarts == 0 {
+		t.Error("expected the search to restart")
+	}
+	// Restarts revisit the same grids, but must neither repeat nor lose any.
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %d grids with restarts, got %d", len(want), len(got))
+	}
+	if len(want) == 0 {
+		t.Error("expected at least one grid")
+	}
+}
@@ -0,0 +1,334 @@
+package xwgen
+
+import (
+	"cmp"
+	"context"
+	"iter"
+	"math"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/Eyas/xwgen/pkg/primitives"
+)
+
+// defaultSampleCandidates is the number of walks Sample makes for each grid if SampleCandidates is
+// zero.
+const defaultSampleCandidates = 8
+
+// sampleExhaustiveBelow is the bound on the number of grids under a node below which a walk lists
+// them all and picks one uniformly, rather than walking further down.
+const sampleExhaustiveBelow = 1000
+
+// Sample returns an endless sequence of grids drawn approximately uniformly at random from those
+// PossibleGrids would yield, so the same grid may come up more than once. Rotations and reflections
+// of a grid count separately. More SampleCandidates make the draws closer to uniform.
+func (g *Generator) Sample(ctx context.Context) iter.Seq[Grid] {
+	return g.sample(ctx, func(*gridState) {})
+}
+
+// SampleFrom is like Sample, for the grids that fill the given pattern (see PossibleGridsFrom). It
+// returns an error if the pattern doesn't match the generator's grid size.
+func (g *Generator) SampleFrom(ctx context.Context, pattern Grid) (iter.Seq[Grid], error) {
+	if err := checkPattern(pattern, g.LineLength); err != nil {
+		return nil, err
+	}
+	cells := patternCells(pattern)
+	return g.sample(ctx, func(s *gridState) { s.applyCells(cells) }), nil
+}
+
+// sample returns grids sampled from those reachable from the initial state, once narrowed down by
+// setup.
+func (g *Generator) sample(ctx context.Context, setup func(*gridState)) iter.Seq[Grid] {
+	return func(yield func(Grid) bool) {
+		g.searchErr = nil
+		root, err := g.initialState(ctx)
+		if err != nil {
+			g.searchErr = err
+			return
+		}
+		setup(&root)
+
+		progress := root.config.progress
+		defer func() {
+			g.searchStats = progress.finish()
+		}()
+
+		// Nogoods are learned from the order of the decisions of a search, which walks don't follow.
+		root.config.conflictLearning = false
+		propagated, _, ok := propagate(ctx, &root)
+		if !ok {
+			return
+		}
+		s := sampler{root: newSampleNode(propagated)}
+		walks := make([]sampleWalk, cmp.Or(g.SampleCandidates, defaultSampleCandidates))
+		for {
+			for i := range walks {
+				for {
+					if ctx.Err() != nil || s.root.dead() {
+						return
+					}
+					if walk, ok := s.walk(ctx); ok {
+						walks[i] = walk
+						progress.stats.Grids++
+						break
+					}
+				}
+			}
+			weights := make([]float64, len(walks))
+			for i, walk := range walks {
+				weights[i] = -walk.logProb
+			}
+			i, _ := pickWeighted(weights, root.rand)
+			if !yield(walks[i].fill.grid()) {
+				return
+			}
+		}
+	}
+}
+
+// sampleMaxNodes bounds the number of nodes a sampler keeps. Past that, it starts over from an
+// unexpanded root.
+const sampleMaxNodes = 100_000
+
+// sampler holds the tree that Sample walks down, so that each node is only expanded once, and walks
+// learn to avoid the dead ends found by earlier walks.
+type sampler struct {
+	root  *sampleNode
+	nodes int
+}
+
+// sampleNode is a node of a sampler's tree.
+type sampleNode struct {
+	state gridState
+	// size is the log of the number of grids under the node: estimateGrids at first, then the sum of
+	// the sizes of its children, which become exact as the walks list the fills under them.
+	size     float64
+	expanded bool
+	children []*sampleNode
+	// leaf is true if the node had few enough grids under it to list them all in fills.
+	leaf  bool
+	fills []fill
+}
+
+func newSampleNode(state gridState) *sampleNode {
+	return &sampleNode{state: state, size: estimateGrids(&state)}
+}
+
+// dead returns true if the node is known to have no grids under it.
+func (n *sampleNode) dead() bool {
+	return math.IsInf(n.size, -1)
+}
+
+// resize sets the size of an expanded node from its fills or its children.
+func (n *sampleNode) resize() {
+	if n.leaf {
+		n.size = math.Log(float64(len(n.fills)))
+		return
+	}
+	sizes := make([]float64, len(n.children))
+	for i, child := range n.children {
+		sizes[i] = child.size
+	}
+	n.size = logSumExp(sizes)
+}
+
+// sampleWalk is a fill reached by a random walk, with the log of the probability of that walk.
+type sampleWalk struct {
+	fill    fill
+	logProb float64
+}
+
+// walk walks down from the root to a single fill, taking each branch with a probability
+// proportional to its size. It returns false if it reaches a dead end instead, which later walks
+// avoid.
+func (s *sampler) walk(ctx context.Context) (sampleWalk, bool) {
+	if s.nodes > sampleMaxNodes {
+		s.root, s.nodes = newSampleNode(s.root.state), 0
+	}
+	var path []*sampleNode
+	// What the walk learned about the sizes of the nodes it went through is passed up to the root.
+	defer func() {
+		for i := len(path) - 1; i >= 0; i-- {
+			path[i].resize()
+		}
+	}()
+	var logProb float64
+	node := s.root
+	for {
+		if ctx.Err() != nil {
+			return sampleWalk{}, false
+		}
+		if !node.expanded {
+			if s.expand(ctx, node); !node.expanded {
+				return sampleWalk{}, false
+			}
+		}
+		if node.dead() {
+			return sampleWalk{}, false
+		}
+		if node.leaf {
+			return sampleWalk{
+				fill:    node.fills[node.state.rand.IntN(len(node.fills))],
+				logProb: logProb - math.Log(float64(len(node.fills))),
+			}, true
+		}
+		sizes := make([]float64, len(node.children))
+		for i, child := range node.children {
+			sizes[i] = child.size
+		}
+		i, p := pickWeighted(sizes, node.state.rand)
+		path = append(path, node)
+		logProb += math.Log(p)
+		node = node.children[i]
+	}
+}
+
+// expand finds the branches of node, or the fills under it if there are few enough of them.
+func (s *sampler) expand(ctx context.Context, node *sampleNode) {
+	state := &node.state
+	state.config.progress.visit(state.depth())
+	dir, index, ok := state.undecidedLine()
+	if !ok || maxGrids(state) < math.Log(sampleExhaustiveBelow) {
+		// The fills still need the checks of the search, e.g. for duplicate words. A grid the search
+		// reaches twice is listed once, or it would be sampled twice as often.
+		seen := make(map[string]bool)
+		possibleGridsAtRoot(ctx, state, func(f fill) bool {
+			if repr := f.grid().Repr(); !seen[repr] {
+				seen[repr] = true
+				node.fills = append(node.fills, f)
+			}
+			return true
+		})
+		node.leaf = true
+	} else {
+		for _, child := range sampleBranches(ctx, state, dir, index) {
+			node.children = append(node.children, newSampleNode(child))
+		}
+		s.nodes += len(node.children)
+	}
+	// A node cut short by the end of the context is left unexpanded, and the walk with it.
+	if node.expanded = ctx.Err() == nil; node.expanded {
+		node.resize()
+	}
+}
+
+// undecidedLine returns the line a search would decide next, and false if every line is decided.
+func (s *gridState) undecidedLine() (Direction, int, bool) {
+	down, across := s.getUndecidedIndexDown(), s.getUndecidedIndexAcross()
+	switch {
+	case down == nil && across == nil:
+		return 0, 0, false
+	case across == nil || (down != nil && s.down[*down].MaxPossibilities() <= s.across[*across].MaxPossibilities()):
+		return DirectionVertical, *down, true
+	default:
+		return DirectionHorizontal, *across, true
+	}
+}
+
+// sampleBranches returns the children of state that narrow down the given line, propagated.
+// Children known to be dead ends are left out.
+//
+// Like the search, it splits lines with many possibilities in two, and tries every possibility of
+// the others.
+func sampleBranches(ctx context.Context, state *gridState, dir Direction, index int) []gridState {
+	lines := state.across
+	if dir == DirectionVertical {
+		lines = state.down
+	}
+	line := lines[index]
+	var options []primitives.PossibleLines
+	if line.MaxPossibilities() >= 10 {
+		c := line.MakeChoice()
+		options = []primitives.PossibleLines{c.Choice, c.Remaining}
+	} else {
+		for l := range line.Iterate() {
+			options = append(options, primitives.MakeDefinite(l))
+		}
+	}
+
+	var children []gridState
+	for _, option := range options {
+		narrowed := slices.Clone(lines)
+		narrowed[index] = option
+		child := state.withLines(state.down, narrowed)
+		if dir == DirectionVertical {
+			child = state.withLines(narrowed, state.across)
+		}
+		child.path = append(slices.Clone(state.path), decision{dir: dir, index: index})
+
+		propagated, _, ok := propagateChanged(ctx, &child, []lineRef{{dir: dir, idx: index}})
+		if !ok {
+			continue
+		}
+		if _, ok := invalidWords(&propagated); ok {
+			continue
+		}
+		children = append(children, propagated)
+	}
+	return children
+}
+
+// maxGrids returns the log of the number of ways to fill the rows of state, or its columns if that
+// is fewer. There can't be more grids than that.
+func maxGrids(state *gridState) float64 {
+	across, down, _ := lineCombinations(state)
+	return min(across, down)
+}
+
+// estimateGrids returns the log of an estimate of the number of grids under state: the number of
+// ways to fill its cells, times the chance that both the rows and the columns form lines if each
+// set of lines is independently as likely to.
+func estimateGrids(state *gridState) float64 {
+	across, down, cells := lineCombinations(state)
+	return min(across+down-cells, across, down)
+}
+
+// lineCombinations returns the logs of the number of ways to fill the rows of state, its columns,
+// and its cells with any of the characters they can have.
+func lineCombinations(state *gridState) (across, down, cells float64) {
+	for _, line := range state.across {
+		across += math.Log(float64(line.MaxPossibilities()))
+		for _, chars := range charsOf(line) {
+			cells += math.Log(float64(chars.Count()))
+		}
+	}
+	for _, line := range state.down {
+		down += math.Log(float64(line.MaxPossibilities()))
+	}
+	return across, down, cells
+}
+
+// logSumExp returns the log of the sum of the exponentials of values, or -Inf if there are none.
+func logSumExp(values []float64) float64 {
+	if len(values) == 0 {
+		return math.Inf(-1)
+	}
+	top := slices.Max(values)
+	if math.IsInf(top, -1) {
+		return top
+	}
+	var sum float64
+	for _, v := range values {
+		sum += math.Exp(v - top)
+	}
+	return top + math.Log(sum)
+}
+
+// pickWeighted picks an index at random, with a probability proportional to the exponential of
+// its log weight, and returns it along with that probability.
+func pickWeighted(logWeights []float64, rand *rand.Rand) (int, float64) {
+	top := slices.Max(logWeights)
+	var total float64
+	for _, w := range logWeights {
+		total += math.Exp(w - top)
+	}
+	x := rand.Float64() * total
+	for i, w := range logWeights {
+		p := math.Exp(w - top)
+		if x < p || i == len(logWeights)-1 {
+			return i, p / total
+		}
+		x -= p
+	}
+	panic("unreachable")
+}
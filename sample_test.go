@@ -0,0 +1,84 @@
+package xwgen
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// chiSquaredCritical returns the value a chi-squared statistic with df degrees of freedom exceeds
+// with a probability of 0.001, using the Wilson–Hilferty approximation.
+func chiSquaredCritical(df int) float64 {
+	const z = 3.09 // The 0.999 quantile of the standard normal distribution.
+	k := float64(df)
+	return k * math.Pow(1-2/(9*k)+z*math.Sqrt(2/(9*k)), 3)
+}
+
+func TestSample_Uniform(t *testing.T) {
+	words := loadWords(t)
+	tests := []struct {
+		name    string
+		pattern []string
+		perGrid int
+	}{
+		{name: "few grids", pattern: []string{"s???", "????", "????", "??es"}, perGrid: 40},
+		{name: "many grids", pattern: []string{"????", "????", "????", "???s"}, perGrid: 10},
+		{name: "edge blocks", pattern: []string{"`...`", ".....", "..a..", ".....", "`...s"}, perGrid: 40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern := mustParseGrid(t, strings.Join(tt.pattern, "\n"))
+			gen := testGenerator(pattern.Width(), words, GeneratorParams{MinWordLength: 3})
+
+			all := make(map[string]int)
+			grids, err := gen.PossibleGridsFrom(context.Background(), pattern)
+			if err != nil {
+				t.Fatalf("PossibleGridsFrom() = %v", err)
+			}
+			for grid := range grids {
+				all[grid.Repr()] = 0
+			}
+
+			samples, err := gen.SampleFrom(context.Background(), pattern)
+			if err != nil {
+				t.Fatalf("SampleFrom() = %v", err)
+			}
+			n := 0
+			for grid := range samples {
+				if _, ok := all[grid.Repr()]; !ok {
+					t.Fatalf("sampled a grid PossibleGridsFrom doesn't yield:\n%s", grid.Repr())
+				}
+				all[grid.Repr()]++
+				if n++; n == tt.perGrid*len(all) {
+					break
+				}
+			}
+			if err := gen.Err(); err != nil {
+				t.Fatalf("Err() = %v", err)
+			}
+
+			// Every grid is as likely, so each count is about perGrid.
+			want := float64(tt.perGrid)
+			var chiSquared float64
+			for _, count := range all {
+				chiSquared += (float64(count) - want) * (float64(count) - want) / want
+			}
+			if critical := chiSquaredCritical(len(all) - 1); chiSquared > critical {
+				t.Errorf("chi-squared statistic over %d grids = %.1f, want at most %.1f for uniform samples", len(all), chiSquared, critical)
+			}
+		})
+	}
+}
+
+func TestSample_NoGrids(t *testing.T) {
+	gen := CreateGenerator(4, PreferredAndObscureTiers(loadWords(t), nil), nil, rand.New(rand.NewPCG(1, 2)), GeneratorParams{MinWordLength: 3})
+	samples, err := gen.SampleFrom(context.Background(), mustParseGrid(t, strings.Join([]string{"s...", "....", "....", "...y"}, "\n")))
+	if err != nil {
+		t.Fatalf("SampleFrom() = %v", err)
+	}
+	for grid := range samples {
+		t.Fatalf("sampled a grid for a pattern with no fill:\n%s", grid.Repr())
+	}
+}
@@ -0,0 +1,299 @@
+package xwgen
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/Eyas/xwgen/pkg/primitives"
+)
+
+// FillSession is an editable pattern, for building interactive constructors on top of the
+// generator. It propagates the pattern's constraints after every edit, and every edit can be undone
+// and redone.
+type FillSession struct {
+	gen     *Generator
+	current sessionState
+	undo    []sessionState
+	redo    []sessionState
+}
+
+// sessionState is a snapshot of a FillSession.
+type sessionState struct {
+	cells [][]rune
+	// state is the pattern propagated, and consistent is false if propagation found it has no fill.
+	state      gridState
+	consistent bool
+}
+
+// NewFillSession starts a session editing the given pattern (see ParseGrid).
+func (g *Generator) NewFillSession(ctx context.Context, pattern Grid) (*FillSession, error) {
+	if err := checkPattern(pattern, g.LineLength); err != nil {
+		return nil, err
+	}
+	s := &FillSession{gen: g}
+	cells := make([][]rune, pattern.Height())
+	for y := range cells {
+		cells[y] = slices.Clone(pattern.grid[y])
+	}
+	current, err := s.propagateAll(ctx, cells)
+	if err != nil {
+		return nil, err
+	}
+	s.current = current
+	return s, nil
+}
+
+// Grid returns the current pattern.
+func (s *FillSession) Grid() Grid {
+	return NewGrid(cloneCells(s.current.cells))
+}
+
+// Consistent returns false if the current pattern definitely has no fill, e.g. because some slot
+// has no possible word left.
+func (s *FillSession) Consistent() bool {
+	return s.current.consistent
+}
+
+// Allowed returns the characters that the cell at column x and row y may still hold, including
+// BlockCell if it may be a block. It returns nil if the pattern is not consistent.
+func (s *FillSession) Allowed(x, y int) []rune {
+	if !s.current.consistent {
+		return nil
+	}
+	var across, down primitives.CharSet
+	s.current.state.across[y].CharsAt(&across, x)
+	s.current.state.down[x].CharsAt(&down, y)
+	var allowed []rune
+	for r := rune(BlockCell); r <= 'z'; r++ {
+		if across.Contains(r) && down.Contains(r) {
+			allowed = append(allowed, r)
+		}
+	}
+	return allowed
+}
+
+// PlaceLetter puts letter in the cell at column x and row y.
+func (s *FillSession) PlaceLetter(ctx context.Context, x, y int, letter rune) error {
+	if letter < 'a' || letter > 'z' {
+		return fmt.Errorf("invalid letter %q", letter)
+	}
+	return s.edit(ctx, []Cell{{X: x, Y: y, Value: letter}})
+}
+
+// RemoveLetter empties the cell at column x and row y, so that it can hold any letter.
+func (s *FillSession) RemoveLetter(ctx context.Context, x, y int) error {
+	return s.edit(ctx, []Cell{{X: x, Y: y, Value: OpenCell}})
+}
+
+// ToggleBlock turns the cell at column x and row y into a block, or a block back into an empty
+// cell.
+func (s *FillSession) ToggleBlock(ctx context.Context, x, y int) error {
+	if err := s.checkCell(x, y); err != nil {
+		return err
+	}
+	value := rune(BlockCell)
+	if s.current.cells[y][x] == BlockCell {
+		value = OpenCell
+	}
+	return s.edit(ctx, []Cell{{X: x, Y: y, Value: value}})
+}
+
+// PlaceWord fills the given slot of the current pattern (see PatternSlots) with word.
+func (s *FillSession) PlaceWord(ctx context.Context, slot Slot, word string) error {
+	slot, err := s.slot(slot)
+	if err != nil {
+		return err
+	}
+	if len(word) != slot.Length {
+		return fmt.Errorf("%q doesn't fit %s", word, slot)
+	}
+	for _, r := range word {
+		if r < 'a' || r > 'z' {
+			return fmt.Errorf("invalid word %q", word)
+		}
+	}
+	return s.edit(ctx, slot.cells([]rune(word)))
+}
+
+// RemoveWord empties every cell of the given slot of the current pattern, including the cells it
+// shares with crossing slots.
+func (s *FillSession) RemoveWord(ctx context.Context, slot Slot) error {
+	slot, err := s.slot(slot)
+	if err != nil {
+		return err
+	}
+	values := make([]rune, slot.Length)
+	for i := range values {
+		values[i] = OpenCell
+	}
+	return s.edit(ctx, slot.cells(values))
+}
+
+// Undo reverts the last edit. It returns false if there is nothing to undo.
+func (s *FillSession) Undo() bool {
+	if len(s.undo) == 0 {
+		return false
+	}
+	s.redo = append(s.redo, s.current)
+	s.current = s.undo[len(s.undo)-1]
+	s.undo = s.undo[:len(s.undo)-1]
+	return true
+}
+
+// Redo reapplies the last undone edit. It returns false if there is nothing to redo.
+func (s *FillSession) Redo() bool {
+	if len(s.redo) == 0 {
+		return false
+	}
+	s.undo = append(s.undo, s.current)
+	s.current = s.redo[len(s.redo)-1]
+	s.redo = s.redo[:len(s.redo)-1]
+	return true
+}
+
+// Suggest returns the words that fit the given slot of the current pattern; see
+// Generator.Suggest.
+func (s *FillSession) Suggest(ctx context.Context, slot Slot) ([]Suggestion, error) {
+	return s.gen.Suggest(ctx, s.Grid(), slot)
+}
+
+// Autofill fills the rest of the current pattern with the first grid the generator finds, as a
+// single edit that can be undone. It returns false if no fill was found.
+func (s *FillSession) Autofill(ctx context.Context) (bool, error) {
+	if !s.current.consistent {
+		return false, nil
+	}
+	root := s.current.state
+	// Nogoods learned by a search only hold for its own root, so every autofill starts afresh.
+	root.config = s.gen.searchConfig()
+
+	var grid *Grid
+	possibleGridsAtRoot(ctx, &root, func(f fill) bool {
+		g := f.grid()
+		grid = &g
+		return false
+	})
+	if grid == nil {
+		return false, ctx.Err()
+	}
+
+	var cells []Cell
+	for y := range grid.Height() {
+		for x := range grid.Width() {
+			cells = append(cells, Cell{X: x, Y: y, Value: grid.Get(x, y)})
+		}
+	}
+	return true, s.edit(ctx, cells)
+}
+
+func (s *FillSession) checkCell(x, y int) error {
+	if y < 0 || y >= len(s.current.cells) || x < 0 || x >= len(s.current.cells[y]) {
+		return fmt.Errorf("cell (%d, %d) is outside of the grid", x, y)
+	}
+	return nil
+}
+
+// slot returns the slot of the current pattern that matches the given one.
+func (s *FillSession) slot(slot Slot) (Slot, error) {
+	actual, ok := slotAt(s.Grid(), slot.X, slot.Y, slot.Direction)
+	if !ok || actual.X != slot.X || actual.Y != slot.Y || actual.Length != slot.Length {
+		return Slot{}, fmt.Errorf("no slot %s in pattern", slot)
+	}
+	return actual, nil
+}
+
+// edit sets the given cells, and propagates the result.
+func (s *FillSession) edit(ctx context.Context, edits []Cell) error {
+	for _, c := range edits {
+		if err := s.checkCell(c.X, c.Y); err != nil {
+			return err
+		}
+	}
+
+	cells := cloneCells(s.current.cells)
+	narrowing := true
+	var changed []Cell
+	for _, c := range edits {
+		old := cells[c.Y][c.X]
+		if old == c.Value {
+			continue
+		}
+		if !allowedChars(old).ContainsAll(allowedChars(c.Value)) {
+			narrowing = false
+		}
+		cells[c.Y][c.X] = c.Value
+		changed = append(changed, c)
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	var next sessionState
+	var err error
+	if narrowing {
+		next, err = s.propagateNarrowed(ctx, cells, changed)
+	} else {
+		next, err = s.propagateAll(ctx, cells)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.undo = append(s.undo, s.current)
+	s.redo = nil
+	s.current = next
+	return nil
+}
+
+// propagateAll propagates the given pattern from scratch.
+func (s *FillSession) propagateAll(ctx context.Context, cells [][]rune) (sessionState, error) {
+	state, err := s.gen.initialState(ctx)
+	if err != nil {
+		return sessionState{}, err
+	}
+	state.applyCells(patternCells(NewGrid(cells)))
+	return s.check(ctx, sessionState{cells: cells, state: state}, nil)
+}
+
+// propagateNarrowed propagates the current state after the changed cells, which only add
+// constraints, were applied to it.
+func (s *FillSession) propagateNarrowed(ctx context.Context, cells [][]rune, changed []Cell) (sessionState, error) {
+	next := sessionState{cells: cells, state: s.current.state}
+	if !s.current.consistent {
+		// Adding constraints can't make the pattern consistent again.
+		return next, nil
+	}
+	next.state = next.state.withLines(slices.Clone(next.state.down), slices.Clone(next.state.across))
+	next.state.applyCells(changed)
+
+	var lines []lineRef
+	for _, c := range changed {
+		lines = append(lines, lineRef{dir: DirectionHorizontal, idx: c.Y}, lineRef{dir: DirectionVertical, idx: c.X})
+	}
+	return s.check(ctx, next, lines)
+}
+
+// check propagates the state of next from the changed lines (or all of them, if nil), and records
+// whether it is consistent.
+func (s *FillSession) check(ctx context.Context, next sessionState, changed []lineRef) (sessionState, error) {
+	if _, ok := next.state.impossibleLines(); ok {
+		return next, nil
+	}
+	propagated, _, ok := propagateChanged(ctx, &next.state, changed)
+	if !ok {
+		return next, ctx.Err()
+	}
+	next.state = propagated
+	_, invalid := invalidWords(&next.state)
+	next.consistent = !invalid
+	return next, nil
+}
+
+func cloneCells(cells [][]rune) [][]rune {
+	clone := make([][]rune, len(cells))
+	for y, row := range cells {
+		clone[y] = slices.Clone(row)
+	}
+	return clone
+}
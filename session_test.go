@@ -0,0 +1,102 @@
+package xwgen
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestFillSession(t *testing.T) {
+	ctx := context.Background()
+	words := loadWords(t)
+	gen := CreateGenerator(4, PreferredAndObscureTiers(words, nil), nil, rand.New(rand.NewPCG(1, 2)), GeneratorParams{MinWordLength: 3})
+	session, err := gen.NewFillSession(ctx, mustParseGrid(t, "....\n....\n....\n...."))
+	if err != nil {
+		t.Fatalf("NewFillSession returned error: %v", err)
+	}
+	if !session.Consistent() {
+		t.Fatal("expected an empty pattern to be consistent")
+	}
+
+	slot := PatternSlots(session.Grid())[0]
+	if err := session.PlaceWord(ctx, slot, "spas"); err != nil {
+		t.Fatalf("PlaceWord returned error: %v", err)
+	}
+	if got := session.Allowed(0, 0); !slices.Equal(got, []rune{'s'}) {
+		t.Errorf("expected only 's' to be allowed at (0, 0), got %q", string(got))
+	}
+	if slices.Contains(session.Allowed(0, 1), BlockCell) {
+		t.Error("expected (0, 1) to hold a letter")
+	}
+
+	if err := session.PlaceLetter(ctx, 0, 1, 'q'); err != nil {
+		t.Fatalf("PlaceLetter returned error: %v", err)
+	}
+	if err := session.PlaceLetter(ctx, 0, 2, 'x'); err != nil {
+		t.Fatalf("PlaceLetter returned error: %v", err)
+	}
+	if session.Consistent() {
+		t.Errorf("expected no fill with a down entry starting with %q", "sqx")
+	}
+
+	if !session.Undo() || !session.Undo() {
+		t.Fatal("expected to undo the letters")
+	}
+	if !session.Consistent() {
+		t.Error("expected undo to restore consistency")
+	}
+	if !session.Redo() || session.Grid().Get(0, 1) != 'q' {
+		t.Error("expected redo to place the letter again")
+	}
+	if err := session.RemoveLetter(ctx, 0, 1); err != nil {
+		t.Fatalf("RemoveLetter returned error: %v", err)
+	}
+	if session.Redo() {
+		t.Error("expected an edit to clear the redo history")
+	}
+
+	// Incremental propagation must agree with propagating the whole pattern again.
+	fresh, err := gen.NewFillSession(ctx, session.Grid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for y := range 4 {
+		for x := range 4 {
+			if got, want := session.Allowed(x, y), fresh.Allowed(x, y); !slices.Equal(got, want) {
+				t.Errorf("cell (%d, %d): incremental propagation allows %q, full propagation %q", x, y, string(got), string(want))
+			}
+		}
+	}
+
+	ok, err := session.Autofill(ctx)
+	if err != nil || !ok {
+		t.Fatalf("expected Autofill to find a fill, got %v, %v", ok, err)
+	}
+	filled := session.Grid()
+	if filled.Repr()[:4] != "spas" {
+		t.Errorf("expected the fill to keep the placed word, got:\n%s", filled.Repr())
+	}
+	for _, c := range patternCells(filled) {
+		if c.Value == OpenCell {
+			t.Errorf("expected every cell to be filled, got:\n%s", filled.Repr())
+			break
+		}
+	}
+	if !session.Undo() || session.Grid().Get(3, 3) != OpenCell {
+		t.Error("expected to undo the autofill")
+	}
+
+	if err := session.ToggleBlock(ctx, 3, 3); err != nil {
+		t.Fatalf("ToggleBlock returned error: %v", err)
+	}
+	if session.Grid().Get(3, 3) != BlockCell {
+		t.Error("expected a block")
+	}
+	if err := session.ToggleBlock(ctx, 3, 3); err != nil || session.Grid().Get(3, 3) != OpenCell {
+		t.Error("expected toggling again to remove the block")
+	}
+	if err := session.PlaceWord(ctx, slot, "toolong"); err == nil {
+		t.Error("expected an error for a word that doesn't fit")
+	}
+}
@@ -0,0 +1,223 @@
+package xwgen
+
+import (
+	"context"
+	"iter"
+	"slices"
+
+	"github.com/Eyas/xwgen/pkg/primitives"
+)
+
+// WordSquares returns the word squares of the generator's size: block-free grids where every row is
+// a word, and the same word as the column with the same index. No word is used twice.
+func (g *Generator) WordSquares(ctx context.Context) iter.Seq[Grid] {
+	words := g.squareWords(g.LineLength)
+	across := make([]primitives.PossibleLines, g.LineLength)
+	for i := range across {
+		across[i] = words
+	}
+	// Row i and column i are the same line, so both directions share the same slice.
+	return g.squares(ctx, squareState{across: across, down: across, symmetric: true})
+}
+
+// WordRectangles returns the block-free grids of the given size where every row and every column
+// is a word, and no word is used twice. With width equal to height, these are double word squares.
+func (g *Generator) WordRectangles(ctx context.Context, width, height int) iter.Seq[Grid] {
+	rows, columns := g.squareWords(width), g.squareWords(height)
+	state := squareState{
+		across: make([]primitives.PossibleLines, height),
+		down:   make([]primitives.PossibleLines, width),
+	}
+	for y := range state.across {
+		state.across[y] = rows
+	}
+	for x := range state.down {
+		state.down[x] = columns
+	}
+	return g.squares(ctx, state)
+}
+
+// squareWords returns the words of the given length, shuffled within each tier.
+func (g *Generator) squareWords(length int) primitives.PossibleLines {
+	words, ok := g.queryWords()[length].(*primitives.Words)
+	if !ok {
+		return primitives.MakeImpossible(length)
+	}
+	tiers := words.Tiers()
+	for t := range tiers {
+		tiers[t] = slices.Clone(tiers[t])
+		if g.rand != nil {
+			g.rand.Shuffle(len(tiers[t]), func(i, j int) { tiers[t][i], tiers[t][j] = tiers[t][j], tiers[t][i] })
+		}
+	}
+	return primitives.MakeWordsFromTiers(tiers, length)
+}
+
+// squareState is the state of a block-free grid being filled: across[y] is the set of possible
+// rows at y, and down[x] the set of possible columns at x. In a symmetric state, across and down
+// are the same slice.
+type squareState struct {
+	across, down []primitives.PossibleLines
+	symmetric    bool
+}
+
+// clone returns a copy of s whose lines can be narrowed without affecting s.
+func (s squareState) clone() squareState {
+	c := squareState{across: slices.Clone(s.across), symmetric: s.symmetric}
+	if s.symmetric {
+		c.down = c.across
+	} else {
+		c.down = slices.Clone(s.down)
+	}
+	return c
+}
+
+// directions returns the line slices to narrow: both of them, or only across in a symmetric state,
+// where they are the same.
+func (s squareState) directions() [][2][]primitives.PossibleLines {
+	if s.symmetric {
+		return [][2][]primitives.PossibleLines{{s.across, s.down}}
+	}
+	return [][2][]primitives.PossibleLines{{s.across, s.down}, {s.down, s.across}}
+}
+
+// propagate narrows every line of s to those that agree with their crossing lines, and removes the
+// words of decided lines from every other line. It returns false if some line is left with no
+// possibilities.
+func (s squareState) propagate(ctx context.Context, config *searchConfig) bool {
+	for changed := true; changed; {
+		if ctx.Err() != nil {
+			return false
+		}
+		changed = false
+		for _, dir := range s.directions() {
+			lines, crossing := dir[0], dir[1]
+			for i, line := range lines {
+				for j, cross := range crossing {
+					if s.symmetric && i == j {
+						continue
+					}
+					var chars primitives.CharSet
+					cross.CharsAt(&chars, i)
+					line = line.FilterAny(&chars, j)
+				}
+				if impossible(line) {
+					return false
+				}
+				if line != lines[i] {
+					lines[i] = line
+					changed = true
+				}
+			}
+		}
+
+		// Decided lines use up their words.
+		all := slices.Concat(s.across, s.down)
+		if s.symmetric {
+			all = s.across
+		}
+		for i, line := range all {
+			if line.MaxPossibilities() != 1 {
+				continue
+			}
+			// Related words may have another length, e.g. a row "acts" and a column "act".
+			used := config.withRelated(line.DefiniteWords())
+			for j, other := range all {
+				if i == j {
+					continue
+				}
+				if narrowed := other.RemoveWordOptions(used); narrowed != other {
+					if impossible(narrowed) {
+						return false
+					}
+					s.set(j, narrowed)
+					all[j] = narrowed
+					changed = true
+				}
+			}
+		}
+	}
+	return true
+}
+
+// set replaces the line at index i of slices.Concat(s.across, s.down).
+func (s squareState) set(i int, line primitives.PossibleLines) {
+	if i < len(s.across) {
+		s.across[i] = line
+	} else {
+		s.down[i-len(s.across)] = line
+	}
+}
+
+// undecided returns the direction and index of the line with the fewest possibilities among those
+// with more than one, or false if every line is decided.
+func (s squareState) undecided() (Direction, int, bool) {
+	best := int64(0)
+	var dir Direction
+	var index int
+	for d, lines := range [][]primitives.PossibleLines{s.across, s.down} {
+		if s.symmetric && d > 0 {
+			break
+		}
+		for i, line := range lines {
+			if n := line.MaxPossibilities(); n > 1 && (best == 0 || n < best) {
+				best, dir, index = n, Direction(d), i
+			}
+		}
+	}
+	return dir, index, best > 0
+}
+
+// squares yields every fill of the given state.
+func (g *Generator) squares(ctx context.Context, root squareState) iter.Seq[Grid] {
+	config := g.searchConfig()
+	return func(yield func(Grid) bool) {
+		state := root.clone()
+		if !state.propagate(ctx, config) {
+			return
+		}
+		g.iterateSquares(ctx, config, state, yield)
+	}
+}
+
+// iterateSquares yields every fill of a propagated state, splitting the most constrained line in
+// two until every line is decided. It returns false if the iteration should stop.
+func (g *Generator) iterateSquares(ctx context.Context, config *searchConfig, state squareState, yield func(Grid) bool) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	dir, index, ok := state.undecided()
+	if !ok {
+		used := make(map[string]bool)
+		cells := make([][]rune, len(state.across))
+		for y, line := range state.across {
+			cells[y] = line.FirstOrNull().Line
+			used[string(cells[y])] = true
+		}
+		for _, line := range state.down {
+			used[string(line.FirstOrNull().Line)] = true
+		}
+		if (config.noNestedEntries && hasNestedEntry(used)) || config.exceedsWordCaps(used) {
+			return true
+		}
+		return yield(NewGrid(cells))
+	}
+
+	lines := state.across
+	if dir == DirectionVertical {
+		lines = state.down
+	}
+	step := lines[index].MakeChoice()
+	for _, option := range []primitives.PossibleLines{step.Choice, step.Remaining} {
+		child := state.clone()
+		if dir == DirectionVertical {
+			child.down[index] = option
+		} else {
+			child.across[index] = option
+		}
+		if child.propagate(ctx, config) && !g.iterateSquares(ctx, config, child, yield) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+package xwgen
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+)
+
+// rowsAndColumns returns the rows and the columns of a grid.
+func rowsAndColumns(grid Grid) (rows, columns []string) {
+	for y := range grid.Height() {
+		rows = append(rows, string(grid.grid[y]))
+	}
+	for x := range grid.Width() {
+		column := make([]rune, grid.Height())
+		for y := range column {
+			column[y] = grid.Get(x, y)
+		}
+		columns = append(columns, string(column))
+	}
+	return rows, columns
+}
+
+func TestWordSquares(t *testing.T) {
+	words := loadWords(t)
+	gen := CreateGenerator(4, PreferredAndObscureTiers(words, nil), nil, rand.New(rand.NewPCG(1, 2)), GeneratorParams{})
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+
+	count := 0
+	for grid := range gen.WordSquares(ctx) {
+		count++
+		rows, columns := rowsAndColumns(grid)
+		if !slices.Equal(rows, columns) {
+			t.Errorf("rows %v differ from columns %v", rows, columns)
+		}
+		for i, row := range rows {
+			if !slices.Contains(words, row) {
+				t.Errorf("row %q is not in the word list", row)
+			}
+			if slices.Contains(rows[:i], row) {
+				t.Errorf("row %q is used twice:\n%s", row, grid.Repr())
+			}
+		}
+		if count >= 3 {
+			break
+		}
+	}
+	if count == 0 {
+		t.Error("expected at least one word square")
+	}
+}
+
+func TestWordRectangles(t *testing.T) {
+	words := loadWords(t)
+	tests := []struct {
+		name          string
+		width, height int
+	}{
+		{name: "double word square", width: 4, height: 4},
+		{name: "rectangle", width: 5, height: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := CreateGenerator(tt.width, PreferredAndObscureTiers(words, nil), nil, rand.New(rand.NewPCG(1, 2)), GeneratorParams{})
+
+			ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+			defer cancel()
+
+			count := 0
+			for grid := range gen.WordRectangles(ctx, tt.width, tt.height) {
+				count++
+				if grid.Width() != tt.width || grid.Height() != tt.height {
+					t.Fatalf("got a %dx%d grid, want %dx%d", grid.Width(), grid.Height(), tt.width, tt.height)
+				}
+				rows, columns := rowsAndColumns(grid)
+				entries := append(rows, columns...)
+				for i, entry := range entries {
+					if !slices.Contains(words, entry) {
+						t.Errorf("entry %q is not in the word list", entry)
+					}
+					if slices.Contains(entries[:i], entry) {
+						t.Errorf("entry %q is used twice:\n%s", entry, grid.Repr())
+					}
+				}
+				if count >= 3 {
+					break
+				}
+			}
+			if count == 0 {
+				t.Error("expected at least one word rectangle")
+			}
+		})
+	}
+}
+
+func TestWordRectangles_Rules(t *testing.T) {
+	// The words make a single 4x3 rectangle, whose row "arts" is related to its column "art" and
+	// contains it.
+	words := []string{"aca", "amal", "art", "arts", "caro", "los", "mar"}
+	for _, tc := range []struct {
+		name   string
+		params GeneratorParams
+		want   []string
+	}{
+		{name: "none", want: []string{joinRows("amal", "caro", "arts")}},
+		{name: "related", params: GeneratorParams{Related: EnglishStemmer{}}},
+		{name: "nested entries", params: GeneratorParams{NoNestedEntries: true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for grid := range testGenerator(4, words, tc.params).WordRectangles(t.Context(), 4, 3) {
+				got = append(got, grid.Repr())
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("WordRectangles() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWordRectangles_NoWords(t *testing.T) {
+	gen := CreateGenerator(25, PreferredAndObscureTiers(loadWords(t), nil), nil, rand.New(rand.NewPCG(1, 2)), GeneratorParams{})
+	for _, size := range []struct{ width, height int }{{25, 4}, {4, 30}, {25, 25}} {
+		for grid := range gen.WordRectangles(t.Context(), size.width, size.height) {
+			t.Errorf("WordRectangles(%d, %d) yielded a grid, but no word has %d letters:\n%s", size.width, size.height, max(size.width, size.height), grid.Repr())
+		}
+	}
+	for grid := range gen.WordSquares(t.Context()) {
+		t.Errorf("WordSquares() yielded a grid, but no word has 25 letters:\n%s", grid.Repr())
+	}
+}
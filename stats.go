@@ -0,0 +1,141 @@
+package xwgen
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PruneReason is the reason a branch of the search was abandoned.
+type PruneReason int
+
+const (
+	// PruneImpossibleLine means some line had no possibilities left.
+	PruneImpossibleLine PruneReason = iota
+	// PruneDuplicateWord means some word, or a row and a column, appeared twice.
+	PruneDuplicateWord
+	// PruneWordRule means the words broke some other word rule, e.g. a tier cap or nested entries.
+	PruneWordRule
+	// PruneBlockDensity means the grid had too many blocks.
+	PruneBlockDensity
+	// PruneDividedBoard means the blocks divided the grid into disconnected parts.
+	PruneDividedBoard
+	// PruneStructure means the blocks broke one of the StructureRules or CheckingRules.
+	PruneStructure
+	// PruneNogood means the branch contained a combination of lines already known to fail.
+	PruneNogood
+	// PruneSymmetry means the branch's grids were all transpositions of grids in other branches.
+	PruneSymmetry
+
+	numPruneReasons
+)
+
+func (r PruneReason) String() string {
+	switch r {
+	case PruneImpossibleLine:
+		return "impossible line"
+	case PruneDuplicateWord:
+		return "duplicate word"
+	case PruneWordRule:
+		return "word rule"
+	case PruneBlockDensity:
+		return "block density"
+	case PruneDividedBoard:
+		return "divided board"
+	case PruneStructure:
+		return "structure"
+	case PruneNogood:
+		return "nogood"
+	case PruneSymmetry:
+		return "symmetry"
+	default:
+		return fmt.Sprintf("PruneReason(%d)", int(r))
+	}
+}
+
+// SearchStats is a snapshot of the progress of a search.
+type SearchStats struct {
+	// Nodes is the number of search nodes expanded.
+	Nodes int64
+	// Prunes counts the abandoned branches, indexed by PruneReason.
+	Prunes [numPruneReasons]int64
+	// Grids is the number of grids found, including grids that were not yielded because they had
+	// already been found.
+	Grids int64
+	// Depth is the number of decisions leading to the most recently expanded node, and MaxDepth the
+	// largest depth seen so far.
+	Depth    int
+	MaxDepth int
+	// Elapsed is the time since the search started.
+	Elapsed time.Duration
+	// Propagation and Conflicts count the work done by propagation and by conflict learning.
+	Propagation PropagationStats
+	Conflicts   ConflictStats
+}
+
+func (s SearchStats) String() string {
+	var prunes []string
+	for r, n := range s.Prunes {
+		prunes = append(prunes, fmt.Sprintf("%s %d", PruneReason(r), n))
+	}
+	return fmt.Sprintf("%v elapsed, %d nodes, %d grids, depth %d (max %d); prunes: %s",
+		s.Elapsed.Round(time.Millisecond), s.Nodes, s.Grids, s.Depth, s.MaxDepth, strings.Join(prunes, ", "))
+}
+
+// observeCheckInterval is the number of nodes expanded between checks of whether the observer is
+// due, so that the search doesn't read the clock at every node.
+const observeCheckInterval = 256
+
+// searchProgress tracks the stats of a running search and reports them to the observer.
+type searchProgress struct {
+	stats SearchStats
+	start time.Time
+
+	observer     func(SearchStats)
+	interval     time.Duration
+	lastObserved time.Time
+}
+
+func newSearchProgress(observer func(SearchStats), interval time.Duration) *searchProgress {
+	now := time.Now()
+	return &searchProgress{start: now, observer: observer, interval: interval, lastObserved: now}
+}
+
+// visit records the expansion of a node at the given depth.
+func (p *searchProgress) visit(depth int) {
+	p.stats.Nodes++
+	p.stats.Depth = depth
+	p.stats.MaxDepth = max(p.stats.MaxDepth, depth)
+	if p.observer != nil && p.stats.Nodes%observeCheckInterval == 0 && time.Since(p.lastObserved) >= p.interval {
+		p.observe()
+	}
+}
+
+func (p *searchProgress) prune(reason PruneReason) {
+	p.stats.Prunes[reason]++
+}
+
+// snapshot returns the current stats.
+func (p *searchProgress) snapshot() SearchStats {
+	stats := p.stats
+	stats.Elapsed = time.Since(p.start)
+	return stats
+}
+
+// finish reports the final stats to the observer, if there is one, and returns them.
+func (p *searchProgress) finish() SearchStats {
+	stats := p.snapshot()
+	if p.observer != nil {
+		p.observer(stats)
+	}
+	return stats
+}
+
+// observe reports the current stats to the observer, if there is one.
+func (p *searchProgress) observe() {
+	if p.observer == nil {
+		return
+	}
+	p.lastObserved = time.Now()
+	p.observer(p.snapshot())
+}
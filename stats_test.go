@@ -0,0 +1,45 @@
+package xwgen
+
+import (
+	"context"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestPossibleGrids_Observer(t *testing.T) {
+	words := loadWords(t)
+	var observed []SearchStats
+	gen := CreateGenerator(4, PreferredAndObscureTiers(words, nil), nil, rand.New(rand.NewPCG(1, 2)), GeneratorParams{
+		MinWordLength: 3,
+		Observer: func(stats SearchStats) {
+			observed = append(observed, stats)
+		},
+	})
+
+	count := 0
+	for range gen.PossibleGrids(context.Background()) {
+		count++
+		if count >= 500 {
+			break
+		}
+	}
+
+	if len(observed) < 2 {
+		t.Fatalf("expected periodic and final observations, got %d", len(observed))
+	}
+	last := observed[len(observed)-1]
+	if last != gen.SearchStats() {
+		t.Errorf("expected the final observation to match SearchStats, got %v and %v", last, gen.SearchStats())
+	}
+	if last.Grids < int64(count) || last.Nodes == 0 || last.MaxDepth == 0 || last.Elapsed == 0 {
+		t.Errorf("unexpected stats %v", last)
+	}
+	if last.Prunes[PruneImpossibleLine] == 0 {
+		t.Errorf("expected some impossible lines to be pruned, got %v", last)
+	}
+	for i := 1; i < len(observed); i++ {
+		if observed[i].Nodes < observed[i-1].Nodes {
+			t.Errorf("expected node counts to grow, got %d after %d", observed[i].Nodes, observed[i-1].Nodes)
+		}
+	}
+}
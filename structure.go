@@ -0,0 +1,105 @@
+package xwgen
+
+// StructureRules are rules on the layout of blocks that editors commonly enforce.
+type StructureRules struct {
+	// NoBlockClumps rejects grids with a 2x2 square of blocks.
+	NoBlockClumps bool
+	// NoCheaterSquares rejects grids with a cheater square: a block that doesn't change the number of
+	// entries, because in both directions it is next to another block or the edge of the grid.
+	NoCheaterSquares bool
+	// MaxEdgeBlockRun caps the number of consecutive blocks along any edge of the grid. Zero means
+	// no cap.
+	MaxEdgeBlockRun int
+	// MinConnectivity rejects grids where a region of at least MinRegionSize letters is connected to
+	// the rest of the grid through fewer than MinConnectivity cells. For example, 2 rejects
+	// quadrants joined through a single cell. Zero means no requirement beyond the grid being
+	// connected; the cost of the check grows quickly with MinConnectivity.
+	MinConnectivity int
+	// MinRegionSize is the number of letters a region needs, on both sides of the cells joining it
+	// to the rest, for MinConnectivity to apply. Smaller regions, like a corner cell that only has
+	// two neighbours, are ignored. Zero means the minimum word length.
+	MinRegionSize int
+}
+
+func (r StructureRules) enabled() bool {
+	return r.NoBlockClumps || r.NoCheaterSquares || r.MaxEdgeBlockRun > 0 || r.MinConnectivity > 0
+}
+
+// definiteBlocks returns, for every row y and column x, whether the cell is definitely a block.
+func definiteBlocks(state *gridState) [][]bool {
+	blocks := make([][]bool, len(state.across))
+	for y := range blocks {
+		blocks[y] = make([]bool, len(state.down))
+		for x := range blocks[y] {
+			blocks[y][x] = state.across[y].DefinitelyBlockedAt(x) || state.down[x].DefinitelyBlockedAt(y)
+		}
+	}
+	return blocks
+}
+
+// breaksStructure returns true if the blocks that are already definite break one of the rules.
+func breaksStructure(state *gridState, rules StructureRules) bool {
+	if !rules.enabled() {
+		return false
+	}
+	blocks := definiteBlocks(state)
+	height := len(blocks)
+	if height == 0 {
+		return false
+	}
+	width := len(blocks[0])
+	// blockedOrEdge treats cells outside of the grid as blocks.
+	blockedOrEdge := func(x, y int) bool {
+		return x < 0 || y < 0 || x >= width || y >= height || blocks[y][x]
+	}
+
+	for y := range height {
+		for x := range width {
+			if !blocks[y][x] {
+				continue
+			}
+			if rules.NoBlockClumps && x+1 < width && y+1 < height && blocks[y][x+1] && blocks[y+1][x] && blocks[y+1][x+1] {
+				return true
+			}
+			// Unless both neighbours in some direction are letters, removing the block would only
+			// lengthen or leave entries, not change their number.
+			if rules.NoCheaterSquares &&
+				(blockedOrEdge(x-1, y) || blockedOrEdge(x+1, y)) &&
+				(blockedOrEdge(x, y-1) || blockedOrEdge(x, y+1)) {
+				return true
+			}
+		}
+	}
+
+	if rules.MaxEdgeBlockRun > 0 {
+		var top, bottom, left, right [][2]int
+		for x := range width {
+			top = append(top, [2]int{x, 0})
+			bottom = append(bottom, [2]int{x, height - 1})
+		}
+		for y := range height {
+			left = append(left, [2]int{0, y})
+			right = append(right, [2]int{width - 1, y})
+		}
+		for _, edge := range [][][2]int{top, bottom, left, right} {
+			if longestRun(blocks, edge) > rules.MaxEdgeBlockRun {
+				return true
+			}
+		}
+	}
+	return weaklyConnected(state, rules.MinConnectivity, rules.MinRegionSize)
+}
+
+// longestRun returns the longest run of consecutive blocks among the given cells.
+func longestRun(blocks [][]bool, cells [][2]int) int {
+	longest, run := 0, 0
+	for _, c := range cells {
+		if blocks[c[1]][c[0]] {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
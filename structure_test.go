@@ -0,0 +1,99 @@
+package xwgen
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Eyas/xwgen/pkg/primitives"
+)
+
+// stateOf returns a state where every line is exactly the corresponding line of grid.
+func stateOf(t *testing.T, grid Grid) *gridState {
+	t.Helper()
+	state := &gridState{
+		across: make([]primitives.PossibleLines, grid.Height()),
+		down:   make([]primitives.PossibleLines, grid.Width()),
+	}
+	for y := range grid.Height() {
+		var ok bool
+		if state.across[y], ok = fixedLine(grid.grid[y]); !ok {
+			t.Fatalf("row %d is not fixed", y)
+		}
+	}
+	for x := range grid.Width() {
+		column := make([]rune, grid.Height())
+		for y := range column {
+			column[y] = grid.Get(x, y)
+		}
+		state.down[x], _ = fixedLine(column)
+	}
+	return state
+}
+
+func TestBreaksStructure(t *testing.T) {
+	tests := []struct {
+		name  string
+		grid  []string
+		rules StructureRules
+		want  bool
+	}{
+		{name: "no rules", grid: []string{"##aaa", "##aaa", "aaaaa", "aaaaa", "aaaaa"}, want: false},
+		{name: "clump", grid: []string{"aaaaa", "a##aa", "a##aa", "aaaaa", "aaaaa"}, rules: StructureRules{NoBlockClumps: true}, want: true},
+		{name: "no clump", grid: []string{"aaaaa", "a#aaa", "a##aa", "aaaaa", "aaaaa"}, rules: StructureRules{NoBlockClumps: true}, want: false},
+		{name: "corner cheater", grid: []string{"#aaaa", "aaaaa", "aaaaa", "aaaaa", "aaaaa"}, rules: StructureRules{NoCheaterSquares: true}, want: true},
+		{name: "cheater next to block", grid: []string{"aaaaa", "aaaaa", "a##aa", "aa#aa", "aaaaa"}, rules: StructureRules{NoCheaterSquares: true}, want: true},
+		{name: "edge block", grid: []string{"aa#aa", "aaaaa", "aaaaa", "aaaaa", "aaaaa"}, rules: StructureRules{NoCheaterSquares: true}, want: false},
+		{name: "bar", grid: []string{"aaaaa", "aaaaa", "a###a", "aaaaa", "aaaaa"}, rules: StructureRules{NoCheaterSquares: true}, want: false},
+		{name: "edge run", grid: []string{"aaaaa", "aaaa#", "aaaa#", "aaaa#", "aaaaa"}, rules: StructureRules{MaxEdgeBlockRun: 2}, want: true},
+		{name: "short edge run", grid: []string{"aaaaa", "aaaa#", "aaaa#", "aaaaa", "aaaaa"}, rules: StructureRules{MaxEdgeBlockRun: 2}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grid := mustParseGrid(t, strings.Join(tt.grid, "\n"))
+			if got := breaksStructure(stateOf(t, grid), tt.rules); got != tt.want {
+				t.Errorf("breaksStructure(%+v) = %v, want %v", tt.rules, got, tt.want)
+			}
+		})
+	}
+}
+
+// structureWords make eight 4x4 grids: four without blocks, and four with blocks in two corners.
+var structureWords = []string{"acid", "amen", "ares", "can", "caro", "cat", "erie", "esta", "ests", "mist", "most", "nee", "nest", "race", "res", "rise", "rose", "sod", "stat", "tee"}
+
+var (
+	openGrids = []string{
+		joinRows("amen", "rise", "ests", "stat"),
+		joinRows("amen", "rose", "ests", "stat"),
+		joinRows("ares", "mist", "esta", "nest"),
+		joinRows("ares", "most", "esta", "nest"),
+	}
+	cornerGrids = []string{
+		joinRows("`can", "race", "erie", "sod`"),
+		joinRows("`cat", "race", "erie", "sod`"),
+		joinRows("`res", "caro", "acid", "nee`"),
+		joinRows("`res", "caro", "acid", "tee`"),
+	}
+)
+
+func TestPossibleGrids_Structure(t *testing.T) {
+	checkGrids(t, testGenerator(4, structureWords, GeneratorParams{MinWordLength: 3}), "", slices.Concat(openGrids, cornerGrids))
+
+	// Blocks in the corners are cheater squares.
+	gen := testGenerator(4, structureWords, GeneratorParams{
+		MinWordLength: 3,
+		Structure:     StructureRules{NoBlockClumps: true, NoCheaterSquares: true, MaxEdgeBlockRun: 1},
+	})
+	checkGrids(t, gen, "", openGrids)
+}
+
+func TestPossibleGrids_MinConnectivity(t *testing.T) {
+	// In the grids with blocks, the two cells on the diagonal between the blocks split the grid into
+	// halves of six letters. In the grids without blocks, the corner cells are joined to the rest
+	// through two cells, but are too small to count as regions.
+	gen := testGenerator(4, structureWords, GeneratorParams{
+		MinWordLength: 3,
+		Structure:     StructureRules{MinConnectivity: 3},
+	})
+	checkGrids(t, gen, "", openGrids)
+}
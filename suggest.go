@@ -0,0 +1,129 @@
+package xwgen
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/Eyas/xwgen/pkg/primitives"
+)
+
+// Suggestion is a word that fits a slot of a pattern.
+type Suggestion struct {
+	Word string
+	// Score and Tier are the weight and index of the word's tier.
+	Score float64
+	Tier  int
+	// Fillable is false if placing the word leaves some slot of the pattern with no possible fill,
+	// as far as propagation can tell. Fillable words may still turn out to have no fill.
+	Fillable bool
+}
+
+// Suggest returns the words that fit the given slot of pattern, ordered by score.
+//
+// It doesn't search for full fills: a word is fillable if propagating it through the rest of the
+// pattern leaves every line with some possibility.
+func (g *Generator) Suggest(ctx context.Context, pattern Grid, slot Slot) ([]Suggestion, error) {
+	if err := checkPattern(pattern, g.LineLength); err != nil {
+		return nil, err
+	}
+	actual, ok := slotAt(pattern, slot.X, slot.Y, slot.Direction)
+	if !ok || actual.X != slot.X || actual.Y != slot.Y || actual.Length != slot.Length {
+		return nil, fmt.Errorf("no slot %s in pattern", slot)
+	}
+	slot = actual
+
+	root, err := g.initialState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	root.applyCells(patternCells(pattern))
+
+	// The pattern with its crossing letters propagated; words are checked for fillability against it.
+	propagated, _, ok := propagate(ctx, &root)
+	if !ok && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	consistent := ok
+	if consistent {
+		_, invalid := invalidWords(&propagated)
+		consistent = !invalid
+	}
+
+	var suggestions []Suggestion
+	seen := make(map[string]bool)
+	for t, tier := range g.Tiers {
+		for _, word := range tier.Words {
+			if len(word) != slot.Length || seen[word] || !matchesSlot(word, slot.Pattern) {
+				continue
+			}
+			seen[word] = true
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+
+			cells := slot.cells([]rune(word))
+			// The word must be possible in the slot's own line, e.g. it must not be excluded.
+			line := root.slotLine(slot)
+			for _, c := range cells {
+				line = line.FilterAny(allowedChars(c.Value), slot.offset(c))
+			}
+			if impossible(line) {
+				continue
+			}
+
+			suggestion := Suggestion{Word: word, Score: tier.Weight, Tier: t}
+			if consistent {
+				suggestion.Fillable = fillable(ctx, &propagated, cells)
+			}
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+
+	slices.SortStableFunc(suggestions, func(a, b Suggestion) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	return suggestions, ctx.Err()
+}
+
+// fillable returns true if placing the cells in state leaves every line with some possibility.
+func fillable(ctx context.Context, state *gridState, cells []Cell) bool {
+	attempt := state.withLines(slices.Clone(state.down), slices.Clone(state.across))
+	attempt.applyCells(cells)
+	if _, ok := attempt.impossibleLines(); ok {
+		return false
+	}
+	propagated, _, ok := propagate(ctx, &attempt)
+	if !ok {
+		return false
+	}
+	_, invalid := invalidWords(&propagated)
+	return !invalid
+}
+
+// matchesSlot returns true if word agrees with the letters in the slot's pattern.
+func matchesSlot(word, pattern string) bool {
+	for i, p := range []rune(pattern) {
+		if p >= 'a' && p <= 'z' && rune(word[i]) != p {
+			return false
+		}
+	}
+	return true
+}
+
+// offset returns the index of cell c within the line that holds the slot.
+func (s Slot) offset(c Cell) int {
+	if s.Direction == DirectionVertical {
+		return c.Y
+	}
+	return c.X
+}
+
+// slotLine returns the line of the state that holds the slot.
+func (s *gridState) slotLine(slot Slot) primitives.PossibleLines {
+	if slot.Direction == DirectionVertical {
+		return s.down[slot.X]
+	}
+	return s.across[slot.Y]
+}
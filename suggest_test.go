@@ -0,0 +1,69 @@
+package xwgen
+
+import (
+	"context"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestSuggest(t *testing.T) {
+	words := loadWords(t)
+	gen := CreateGenerator(4, PreferredAndObscureTiers(words[:len(words)/2], words[len(words)/2:]), nil, rand.New(rand.NewPCG(1, 2)), GeneratorParams{MinWordLength: 3})
+	pattern, err := ParseGrid("s...\n....\n....\n....")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slot := PatternSlots(pattern)[0]
+
+	suggestions, err := gen.Suggest(context.Background(), pattern, slot)
+	if err != nil {
+		t.Fatalf("Suggest returned error: %v", err)
+	}
+	if len(suggestions) == 0 {
+		t.Fatal("expected suggestions")
+	}
+
+	var fillable, unfillable int
+	for i, s := range suggestions {
+		if len(s.Word) != 4 || s.Word[0] != 's' {
+			t.Errorf("suggestion %q doesn't fit %s", s.Word, slot)
+		}
+		if i > 0 && s.Score > suggestions[i-1].Score {
+			t.Errorf("expected suggestions ordered by score, got %v after %v", s, suggestions[i-1])
+		}
+		if s.Fillable {
+			fillable++
+			continue
+		}
+		unfillable++
+		if unfillable > 3 {
+			continue
+		}
+		// Words that are not fillable really must have no fill.
+		with := []rune(s.Word + "\n....\n....\n....")
+		grids, err := gen.PossibleGridsFrom(context.Background(), mustParseGrid(t, string(with)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for grid := range grids {
+			t.Errorf("expected %q to have no fill, got:\n%s", s.Word, grid.Repr())
+			break
+		}
+	}
+	if fillable == 0 || unfillable == 0 {
+		t.Errorf("expected both fillable and unfillable suggestions, got %d and %d", fillable, unfillable)
+	}
+
+	if _, err := gen.Suggest(context.Background(), pattern, Slot{Direction: DirectionHorizontal, X: 1, Y: 0, Length: 3}); err == nil {
+		t.Error("expected an error for a slot that isn't in the pattern")
+	}
+}
+
+func mustParseGrid(t *testing.T, s string) Grid {
+	t.Helper()
+	grid, err := ParseGrid(s)
+	if err != nil {
+		t.Fatalf("ParseGrid(%q) returned error: %v", s, err)
+	}
+	return grid
+}
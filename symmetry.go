@@ -0,0 +1,135 @@
+package xwgen
+
+import "github.com/Eyas/xwgen/pkg/primitives"
+
+// symmetry is one of the eight symmetries of a square (the dihedral group): a reflection in the
+// main diagonal, if transpose, followed by reflections that reverse the columns and the rows.
+type symmetry struct {
+	transpose, flipX, flipY bool
+}
+
+// symmetries lists every symmetry of a square, starting with the identity.
+func symmetries() []symmetry {
+	var all []symmetry
+	for _, transpose := range []bool{false, true} {
+		for _, flipX := range []bool{false, true} {
+			for _, flipY := range []bool{false, true} {
+				all = append(all, symmetry{transpose: transpose, flipX: flipX, flipY: flipY})
+			}
+		}
+	}
+	return all
+}
+
+// transform returns the grid g is mapped to by s, bars included.
+func (g Grid) transform(s symmetry) Grid {
+	width, height := g.Width(), g.Height()
+	if s.transpose {
+		width, height = height, width
+	}
+	// source returns the cell of g that ends up at (x, y).
+	source := func(x, y int) (int, int) {
+		if s.flipX {
+			x = width - 1 - x
+		}
+		if s.flipY {
+			y = height - 1 - y
+		}
+		if s.transpose {
+			return y, x
+		}
+		return x, y
+	}
+	// barBetween returns true if there is a bar between two neighbouring cells of g.
+	barBetween := func(x1, y1, x2, y2 int) bool {
+		if y1 == y2 {
+			return g.BarRight(min(x1, x2), y1)
+		}
+		return g.BarBelow(x1, min(y1, y2))
+	}
+
+	cells := make([][]rune, height)
+	var right, below [][]bool
+	if g.Barred() {
+		right = make([][]bool, height)
+		below = make([][]bool, height)
+	}
+	for y := range height {
+		cells[y] = make([]rune, width)
+		if g.Barred() {
+			right[y] = make([]bool, width)
+			below[y] = make([]bool, width)
+		}
+		for x := range width {
+			sx, sy := source(x, y)
+			cells[y][x] = g.Get(sx, sy)
+			if !g.Barred() {
+				continue
+			}
+			if x+1 < width {
+				nx, ny := source(x+1, y)
+				right[y][x] = barBetween(sx, sy, nx, ny)
+			}
+			if y+1 < height {
+				nx, ny := source(x, y+1)
+				below[y][x] = barBetween(sx, sy, nx, ny)
+			}
+		}
+	}
+	return Grid{grid: cells, right: right, below: below}
+}
+
+// Canonical returns the representative of the grids that g can be rotated or reflected into: the
+// one with the smallest Repr. Two grids are rotations or reflections of one another if, and only if,
+// they have the same canonical form.
+func (g Grid) Canonical() Grid {
+	best, bestRepr := g, g.Repr()
+	for _, s := range symmetries()[1:] {
+		t := g.transform(s)
+		if repr := t.Repr(); repr < bestRepr {
+			best, bestRepr = t, repr
+		}
+	}
+	return best
+}
+
+// transposeIsSmaller returns true if the grids of state definitely come after their transpositions
+// when their cells are read row by row. If the rules are the same for the rows and the columns,
+// the transposition of every such grid is also a fill, so with DedupSymmetries the branch can be
+// skipped.
+func transposeIsSmaller(state *gridState) bool {
+	rows := make([][]primitives.CharSet, len(state.across))
+	for y, line := range state.across {
+		rows[y] = charsOf(line)
+	}
+	for y := range rows {
+		// Cells on or left of the diagonal were compared with their transpositions on earlier rows.
+		for x := y + 1; x < len(rows[y]); x++ {
+			cell, ok := onlyChar(rows[y][x])
+			if !ok {
+				return false
+			}
+			transposed, ok := onlyChar(rows[x][y])
+			if !ok {
+				return false
+			}
+			if cell != transposed {
+				return cell > transposed
+			}
+		}
+	}
+	return false
+}
+
+// onlyChar returns the character in chars, if there is exactly one.
+func onlyChar(chars primitives.CharSet) (rune, bool) {
+	if chars.Count() != 1 {
+		return 0, false
+	}
+	for r := BlockCell; r <= 'z'; r++ {
+		if chars.Contains(r) {
+			return r, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,94 @@
+package xwgen
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGrid_Canonical(t *testing.T) {
+	grid := mustParseGrid(t, "abc\nde`\nfgh")
+	canonical := grid.Canonical().Repr()
+	for _, s := range symmetries() {
+		if got := grid.transform(s).Canonical().Repr(); got != canonical {
+			t.Errorf("Canonical() of %+v = %q, want %q", s, got, canonical)
+		}
+	}
+	if got := mustParseGrid(t, "abc\nd`e\nfgh").Canonical().Repr(); got == canonical {
+		t.Errorf("Canonical() of a different grid = %q, want something else", got)
+	}
+
+	transposed := grid.transform(symmetry{transpose: true})
+	if diff := cmp.Diff("adf\nbeg\nc`h", transposed.Repr()); diff != "" {
+		t.Errorf("transposed Repr() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGrid_CanonicalBarred(t *testing.T) {
+	grid := NewBarredGrid(
+		[][]rune{[]rune("abc"), []rune("def"), []rune("ghi")},
+		[][]bool{{false, true, false}, {false, false, false}, {true, false, false}},
+		[][]bool{{false, false, false}, {false, false, true}, {false, false, false}},
+	)
+	want := grid.Words()
+	slices.Sort(want)
+	for _, s := range symmetries() {
+		// Reflections spell entries backwards, but the transposition keeps them.
+		if s.flipX || s.flipY {
+			continue
+		}
+		got := grid.transform(s).Words()
+		slices.Sort(got)
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Words() of %+v mismatch (-want +got):\n%s", s, diff)
+		}
+	}
+	if got := grid.transform(symmetry{transpose: true, flipX: true, flipY: true}).Canonical().Repr(); got != grid.Canonical().Repr() {
+		t.Errorf("Canonical() of a rotated barred grid = %q, want %q", got, grid.Canonical().Repr())
+	}
+}
+
+func TestPossibleGrids_DedupSymmetries(t *testing.T) {
+	words := loadWords(t)
+	pattern := mustParseGrid(t, strings.Join([]string{"...`", "....", "....", "`..."}, "\n"))
+
+	classes := func(dedup bool) (int, map[string]bool) {
+		gen := CreateGenerator(4, PreferredAndObscureTiers(words, nil), nil, rand.New(rand.NewPCG(1, 2)), GeneratorParams{
+			MinWordLength:   3,
+			DedupSymmetries: dedup,
+		})
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+		defer cancel()
+		grids, err := gen.PossibleGridsFrom(ctx, pattern)
+		if err != nil {
+			t.Fatalf("PossibleGridsFrom() = %v", err)
+		}
+		count := 0
+		seen := make(map[string]bool)
+		for grid := range grids {
+			count++
+			seen[grid.Canonical().Repr()] = true
+		}
+		if ctx.Err() != nil {
+			t.Fatalf("search did not finish: %v", ctx.Err())
+		}
+		return count, seen
+	}
+
+	_, want := classes(false)
+	count, got := classes(true)
+	if count != len(got) {
+		t.Errorf("got %d grids in %d classes, want one grid per class", count, len(got))
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("classes mismatch (-want +got):\n%s", diff)
+	}
+	if len(want) == 0 {
+		t.Error("expected at least one grid")
+	}
+}
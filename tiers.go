@@ -0,0 +1,59 @@
+package xwgen
+
+// WordTier is a named list of words that the generator treats alike.
+//
+// Tiers are ordered: words in earlier tiers are tried before words in later ones.
+type WordTier struct {
+	Name  string
+	Words []string
+	// Weight is the score of every word in this tier. Higher is better. It doesn't change the order
+	// of the search, which only follows the order of the tiers; it is used by Score, by
+	// GeneratorParams.LowScoreThreshold and to sort the results of Query and Suggest.
+	Weight float64
+	// MaxEntries caps how many entries from this tier a single grid may use. Zero means no cap.
+	MaxEntries int
+}
+
+// PreferredAndObscureTiers returns the two tiers used by the classic preferred/obscure split: every
+// preferred word is tried before any obscure one.
+func PreferredAndObscureTiers(preferredWords, obscureWords []string) []WordTier {
+	return []WordTier{
+		{Name: "preferred", Words: preferredWords, Weight: 1},
+		{Name: "obscure", Words: obscureWords, Weight: 0.5},
+	}
+}
+
+// tierIndex maps every word to the index of the first tier it appears in.
+func tierIndex(tiers []WordTier) map[string]int {
+	idx := make(map[string]int)
+	for t, tier := range tiers {
+		for _, word := range tier.Words {
+			if _, ok := idx[word]; !ok {
+				idx[word] = t
+			}
+		}
+	}
+	return idx
+}
+
+// Score returns the weight of the tier that word belongs to, and false if word is in no tier.
+func (g *Generator) Score(word string) (float64, bool) {
+	t, ok := g.wordTiers()[word]
+	if !ok {
+		return 0, false
+	}
+	return g.Tiers[t].Weight, true
+}
+
+// Tier returns the index of the tier that word belongs to, and false if word is in no tier.
+func (g *Generator) Tier(word string) (int, bool) {
+	t, ok := g.wordTiers()[word]
+	return t, ok
+}
+
+func (g *Generator) wordTiers() map[string]int {
+	if g.lazyWordTiers == nil {
+		g.lazyWordTiers = tierIndex(g.Tiers)
+	}
+	return g.lazyWordTiers
+}